@@ -0,0 +1,149 @@
+// event-sender 本地模拟飞书事件推送，用于在没有飞书环境时测试事件回调
+//
+// 用法示例：
+//
+//	go run ./cmd/event-sender -table tblxxx -record recxxx -token <verification_token> -encrypt-key <encrypt_key>
+//	go run ./cmd/event-sender -challenge -token <verification_token>
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	larkevent "github.com/larksuite/oapi-sdk-go/v3/event"
+)
+
+func main() {
+	url := flag.String("url", "http://localhost:8080/api/events/lark", "事件回调地址")
+	token := flag.String("token", "", "事件订阅Verification Token")
+	encryptKey := flag.String("encrypt-key", "", "事件订阅Encrypt Key，为空时发送明文事件")
+	challenge := flag.Bool("challenge", false, "发送URL校验请求而不是记录变更事件")
+	fileToken := flag.String("file-token", "", "多维表格app_token")
+	tableID := flag.String("table", "", "数据表ID")
+	records := flag.String("record", "", "记录ID，多个用逗号分隔")
+	action := flag.String("action", "record_edited", "操作类型: record_added / record_edited / record_deleted")
+	flag.Parse()
+
+	var payload map[string]interface{}
+	if *challenge {
+		payload = map[string]interface{}{
+			"challenge": "challenge-" + randomHex(8),
+			"token":     *token,
+			"type":      "url_verification",
+		}
+	} else {
+		if *tableID == "" || *records == "" {
+			log.Fatal("发送记录变更事件需要 -table 和 -record 参数")
+		}
+		payload = recordChangedEvent(*token, *fileToken, *tableID, strings.Split(*records, ","), *action)
+	}
+
+	plain, err := json.Marshal(payload)
+	if err != nil {
+		log.Fatalf("构建事件失败: %v", err)
+	}
+
+	body := plain
+	if *encryptKey != "" {
+		encrypted, err := encrypt(plain, *encryptKey)
+		if err != nil {
+			log.Fatalf("加密事件失败: %v", err)
+		}
+		body, _ = json.Marshal(map[string]string{"encrypt": encrypted})
+	}
+
+	req, err := http.NewRequest("POST", *url, bytes.NewReader(body))
+	if err != nil {
+		log.Fatalf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// 与飞书一致，使用Encrypt Key对请求签名
+	if *encryptKey != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		nonce := randomHex(8)
+		req.Header.Set(larkevent.EventRequestTimestamp, timestamp)
+		req.Header.Set(larkevent.EventRequestNonce, nonce)
+		req.Header.Set(larkevent.EventSignature, larkevent.Signature(timestamp, nonce, *encryptKey, string(body)))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatalf("发送事件失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	fmt.Printf("HTTP %d\n%s\n", resp.StatusCode, string(respBody))
+}
+
+// recordChangedEvent 构建 drive.file.bitable_record_changed_v1 事件
+func recordChangedEvent(token, fileToken, tableID string, recordIDs []string, action string) map[string]interface{} {
+	var actions []map[string]interface{}
+	for _, recordID := range recordIDs {
+		actions = append(actions, map[string]interface{}{
+			"record_id": strings.TrimSpace(recordID),
+			"action":    action,
+		})
+	}
+
+	return map[string]interface{}{
+		"schema": "2.0",
+		"header": map[string]interface{}{
+			"event_id":    randomHex(16),
+			"event_type":  "drive.file.bitable_record_changed_v1",
+			"create_time": strconv.FormatInt(time.Now().UnixMilli(), 10),
+			"token":       token,
+			"app_id":      "event-sender",
+			"tenant_key":  "event-sender",
+		},
+		"event": map[string]interface{}{
+			"file_type":   "bitable",
+			"file_token":  fileToken,
+			"table_id":    tableID,
+			"action_list": actions,
+		},
+	}
+}
+
+// encrypt 使用飞书事件加密方式（AES-256-CBC，密钥为Encrypt Key的SHA256）加密事件
+func encrypt(plain []byte, encryptKey string) (string, error) {
+	key := sha256.Sum256([]byte(encryptKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return "", err
+	}
+
+	// PKCS7填充
+	padding := aes.BlockSize - len(plain)%aes.BlockSize
+	plain = append(plain, bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	buf := make([]byte, aes.BlockSize+len(plain))
+	iv := buf[:aes.BlockSize]
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(buf[aes.BlockSize:], plain)
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+// randomHex 生成随机十六进制字符串
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
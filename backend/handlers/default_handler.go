@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"lark-record/models"
+	"lark-record/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var defaultValueService *services.DefaultValueService
+
+// SetDefaultValueService 设置默认值服务
+func SetDefaultValueService(svc *services.DefaultValueService) {
+	defaultValueService = svc
+}
+
+// PreviewDefaultsRequest 预览默认值模板的请求
+type PreviewDefaultsRequest struct {
+	AppToken string             `json:"app_token"`
+	TableID  string             `json:"table_id"`
+	Page     models.PageContext `json:"page"`
+	Template string             `json:"template"` // 指定时只预览该模板，否则预览数据表所有字段的模板默认值
+}
+
+// PreviewDefaults 预览默认值模板的计算结果，不会递增序号
+func PreviewDefaults(c *gin.Context) {
+	if configService == nil || defaultValueService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "默认值服务未初始化"})
+		return
+	}
+
+	var req PreviewDefaultsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := services.DefaultTemplateContext{
+		AppToken: req.AppToken,
+		TableID:  req.TableID,
+		Page:     req.Page,
+		Now:      time.Now(),
+		Location: configService.Location(),
+		Preview:  true,
+	}
+
+	if req.Template != "" {
+		value, err := defaultValueService.Render(req.Template, ctx)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "默认值模板无效: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"value": value})
+		return
+	}
+
+	table := findTableConfig(configService.GetConfig(), req.AppToken, req.TableID)
+	if table == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未找到表格配置"})
+		return
+	}
+
+	values, err := defaultValueService.Preview(table.WriteFields, ctx)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"values": values})
+}
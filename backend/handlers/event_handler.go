@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	larkevent "github.com/larksuite/oapi-sdk-go/v3/event"
+	"github.com/larksuite/oapi-sdk-go/v3/event/dispatcher"
+	larkdrive "github.com/larksuite/oapi-sdk-go/v3/service/drive/v1"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LarkEvent 接收飞书事件回调
+// 支持URL校验（challenge）和Encrypt Key加密的事件，收到多维表格记录变更事件后立即检测匹配的记录
+func LarkEvent(c *gin.Context) {
+	if configService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "配置服务未初始化"})
+		return
+	}
+
+	config := configService.GetConfig()
+	if config.EventVerificationToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "未配置事件订阅Verification Token"})
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取事件内容失败: " + err.Error()})
+		return
+	}
+
+	eventDispatcher := dispatcher.NewEventDispatcher(config.EventVerificationToken, config.EventEncryptKey).
+		OnP2FileBitableRecordChangedV1(handleBitableRecordChanged)
+
+	resp := eventDispatcher.Handle(context.Background(), &larkevent.EventReq{
+		Header:     c.Request.Header,
+		Body:       body,
+		RequestURI: c.Request.RequestURI,
+	})
+
+	for key, values := range resp.Header {
+		for _, value := range values {
+			c.Writer.Header().Add(key, value)
+		}
+	}
+	c.Data(resp.StatusCode, c.Writer.Header().Get("Content-Type"), resp.Body)
+}
+
+// handleBitableRecordChanged 处理多维表格记录变更事件
+func handleBitableRecordChanged(ctx context.Context, event *larkdrive.P2FileBitableRecordChangedV1) error {
+	if event.Event == nil || event.Event.TableId == nil || event.Event.FileToken == nil {
+		return nil
+	}
+
+	// 删除的记录取消检测，其余变更立即重新检测
+	var changedIDs, deletedIDs []string
+	for _, action := range event.Event.ActionList {
+		if action == nil || action.RecordId == nil {
+			continue
+		}
+		if action.Action != nil && *action.Action == "record_deleted" {
+			deletedIDs = append(deletedIDs, *action.RecordId)
+		} else {
+			changedIDs = append(changedIDs, *action.RecordId)
+		}
+	}
+
+	logInfo("📨 收到记录变更事件: 多维表格 %s, 表格 %s, %d 条记录变更, %d 条记录删除", *event.Event.FileToken, *event.Event.TableId, len(changedIDs), len(deletedIDs))
+
+	if watchService != nil {
+		// 按多维表格和数据表匹配检测任务，不同多维表格中的数据表ID可能相同
+		appTokens := []string{*event.Event.FileToken}
+		config := configService.GetConfig()
+		if larkService := serviceManager.GetLarkService(config.AppID, config.AppSecret); larkService != nil {
+			appTokens = larkService.EventAppTokens(*event.Event.FileToken)
+		}
+		for _, appToken := range appTokens {
+			watchService.RecordEventReceived(appToken, *event.Event.TableId)
+			if len(deletedIDs) > 0 {
+				watchService.HandleRecordDeleted(appToken, *event.Event.TableId, deletedIDs)
+			}
+			if len(changedIDs) > 0 {
+				watchService.HandleRecordChanged(appToken, *event.Event.TableId, changedIDs)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"lark-record/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 导出格式对应的Content-Type
+var exportContentTypes = map[string]string{
+	services.ExportFormatCSV:   "text/csv; charset=utf-8",
+	services.ExportFormatXLSX:  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	services.ExportFormatJSONL: "application/x-ndjson; charset=utf-8",
+}
+
+// ExportRecords 导出数据表的所有记录
+// 查询参数：app_token、table_id 数据表，format 导出格式（csv/xlsx/jsonl，默认csv）
+func ExportRecords(c *gin.Context) {
+	if configService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "配置服务未初始化"})
+		return
+	}
+
+	config := configService.GetConfig()
+	if config.AppID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请先配置飞书应用信息"})
+		return
+	}
+
+	appToken := c.Query("app_token")
+	tableID := c.Query("table_id")
+	if appToken == "" || tableID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少必要参数"})
+		return
+	}
+
+	format := c.DefaultQuery("format", services.ExportFormatCSV)
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的导出格式，请使用 csv、xlsx 或 jsonl"})
+		return
+	}
+
+	// 先获取字段，确认数据表可以访问后再开始输出文件
+	larkService := serviceManager.GetLarkService(config.AppID, config.AppSecret)
+	fields, err := larkService.GetTableFields(appToken, tableID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取表格字段失败: " + err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("%s_%s.%s", tableID, time.Now().Format("20060102150405"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	// 获取到第一页记录后才开始输出文件，响应状态在第一次写入时发送
+	count, err := larkService.ExportRecords(appToken, tableID, fields, format, configService.Location(), c.Writer)
+	if err != nil {
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Disposition")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "导出记录失败: " + err.Error()})
+			return
+		}
+		// 文件已开始输出，无法再返回错误响应
+		logError("❌ 导出记录失败（已导出 %d 条）: %v", count, err)
+		return
+	}
+	logInfo("📤 导出 %d 条记录为 %s 文件: %s", count, format, filename)
+}
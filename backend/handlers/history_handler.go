@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"lark-record/models"
+	"lark-record/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var historyService *services.HistoryService
+
+// SetHistoryService 设置提交记录服务
+func SetHistoryService(svc *services.HistoryService) {
+	historyService = svc
+}
+
+// ListHistory 查询提交记录
+// 查询参数：app_token、table_id、status、record_id、client（提交的客户端IP）、from、to（2006-01-02 或 RFC3339，to为日期时包含当天）、limit
+func ListHistory(c *gin.Context) {
+	if historyService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "提交记录服务未初始化"})
+		return
+	}
+
+	query := models.HistoryQuery{
+		AppToken: c.Query("app_token"),
+		TableID:  c.Query("table_id"),
+		Status:   c.Query("status"),
+		RecordID: c.Query("record_id"),
+		Client:   c.Query("client"),
+	}
+
+	loc := time.Local
+	if configService != nil {
+		loc = configService.Location()
+	}
+
+	var err error
+	if query.From, err = services.ParseHistoryTime(c.Query("from"), false, loc); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if query.To, err = services.ParseHistoryTime(c.Query("to"), true, loc); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if raw := c.Query("limit"); raw != "" {
+		if query.Limit, err = strconv.Atoi(raw); err != nil || query.Limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit 必须是正整数"})
+			return
+		}
+	}
+
+	items, total := historyService.Query(query)
+	c.JSON(http.StatusOK, gin.H{
+		"total": total,
+		"items": items,
+	})
+}
+
+// recordSubmission 保存一次新增记录请求的提交记录并返回提交记录ID，未设置提交记录服务时返回空字符串
+// larkService不为nil时按字段类型编码字段值，上传的附件只记录文件名
+func recordSubmission(c *gin.Context, larkService *services.LarkService, req models.AddRecordRequest, uploads map[string][]services.AttachmentUpload, entry models.Submission, err error) string {
+	if historyService == nil {
+		return ""
+	}
+	entry.IdempotencyKey = c.GetString(idempotencyContextKey)
+	return historyService.Record(newSubmission(c, larkService, req, uploads, entry, err)).ID
+}
+
+// newSubmission 根据请求和写入结果生成提交记录
+func newSubmission(c *gin.Context, larkService *services.LarkService, req models.AddRecordRequest, uploads map[string][]services.AttachmentUpload, entry models.Submission, err error) models.Submission {
+	entry.Client = c.ClientIP()
+	entry.UserAgent = c.Request.UserAgent()
+	entry.AppToken = req.AppToken
+	entry.TableID = req.TableID
+	if configService != nil {
+		if table := findTableConfig(configService.GetConfig(), req.AppToken, req.TableID); table != nil {
+			entry.TableName = table.Name
+		}
+	}
+
+	fields := req.Fields
+	if larkService != nil && configService != nil {
+		fields = larkService.NormalizeFields(req.AppToken, req.TableID, fields, configService.RecordOptions())
+	}
+	entry.Fields = make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		entry.Fields[name] = value
+	}
+	for name, files := range uploads {
+		list, _ := entry.Fields[name].([]interface{})
+		for _, file := range files {
+			list = append(list, map[string]interface{}{"name": file.FileName})
+		}
+		entry.Fields[name] = list
+	}
+
+	entry.LarkCode = services.LarkErrorCode(err)
+	if err != nil {
+		entry.Error = err.Error()
+	} else if entry.Status == models.SubmissionQueued {
+		// 客户端要求延后提交的记录尚未访问飞书
+		entry.LarkCode = -1
+	}
+	return entry
+}
+
+// recordBatchSubmissions 为批量新增的每条记录保存提交记录
+func recordBatchSubmissions(c *gin.Context, larkService *services.LarkService, req models.BatchAddRecordsRequest, results []models.BatchRecordResult, watching map[string]bool) {
+	if historyService == nil {
+		return
+	}
+
+	entries := make([]models.Submission, 0, len(results))
+	for _, result := range results {
+		if result.Index < 0 || result.Index >= len(req.Records) {
+			continue
+		}
+		row := models.AddRecordRequest{AppToken: req.AppToken, TableID: req.TableID, Fields: req.Records[result.Index]}
+		entry := models.Submission{Status: models.SubmissionCreated, RecordID: result.RecordID}
+		var err error
+		if result.Error != "" {
+			entry.Status = models.SubmissionFailed
+			err = errors.New(result.Error)
+		} else if watching[result.RecordID] {
+			entry.WatchOutcome = models.WatchOutcomeWatching
+		}
+		entries = append(entries, newSubmission(c, larkService, row, nil, entry, err))
+	}
+	historyService.RecordAll(entries)
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"lark-record/services"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader 客户端提供幂等键的请求头
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// 幂等键的最大长度
+const maxIdempotencyKeyLength = 255
+
+// idempotencyContextKey 保存在请求上下文中的幂等键（含请求方法和路径），提交记录据此在撤销后使幂等键失效
+const idempotencyContextKey = "idempotencyKey"
+
+var idempotencyService *services.IdempotencyService
+
+// SetIdempotencyService 设置幂等键服务
+func SetIdempotencyService(svc *services.IdempotencyService) {
+	idempotencyService = svc
+}
+
+// idempotencyRecorder 在写出响应的同时保存响应内容
+type idempotencyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotent 幂等键中间件
+// 请求带有 Idempotency-Key 请求头时，相同幂等键和请求内容的重复请求直接返回首次成功的响应，
+// 不会再次写入飞书；首次请求失败时不保存结果，客户端可以使用相同的幂等键重试
+func Idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(IdempotencyKeyHeader))
+		if key == "" || idempotencyService == nil {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "幂等键过长"})
+			return
+		}
+
+		fingerprint, err := requestFingerprint(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "读取请求失败: " + err.Error()})
+			return
+		}
+
+		scopedKey := c.Request.Method + " " + c.FullPath() + " " + key
+		entry, err := idempotencyService.Begin(scopedKey, fingerprint)
+		if errors.Is(err, services.ErrIdempotencyInProgress) {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrIdempotencyKeyReused) {
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		if entry != nil {
+			logInfo("🔁 幂等键 %s 重复请求，返回首次请求的结果", key)
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(entry.Status, "application/json; charset=utf-8", entry.Body)
+			c.Abort()
+			return
+		}
+
+		completed := false
+		defer func() {
+			if !completed {
+				idempotencyService.Release(scopedKey)
+			}
+		}()
+
+		c.Set(idempotencyContextKey, scopedKey)
+		recorder := &idempotencyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if status := recorder.Status(); status >= 200 && status < 300 {
+			idempotencyService.Complete(scopedKey, status, recorder.body.Bytes())
+			completed = true
+		}
+	}
+}
+
+// requestFingerprint 计算请求内容的摘要，用于判断幂等键是否被用于不同的请求
+// multipart请求每次的分隔符不同，按表单字段、文件名和文件内容的摘要计算；
+// JSON请求按解析后的内容计算，不受字段顺序和空白的影响；解析后的请求体仍可由处理函数读取
+func requestFingerprint(c *gin.Context) (string, error) {
+	hash := sha256.New()
+
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		form, err := c.MultipartForm()
+		if err != nil {
+			return "", err
+		}
+		valueNames := make([]string, 0, len(form.Value))
+		for name := range form.Value {
+			valueNames = append(valueNames, name)
+		}
+		sort.Strings(valueNames)
+		for _, name := range valueNames {
+			for _, value := range form.Value[name] {
+				fmt.Fprintf(hash, "value %q %q\n", name, value)
+			}
+		}
+		fileNames := make([]string, 0, len(form.File))
+		for name := range form.File {
+			fileNames = append(fileNames, name)
+		}
+		sort.Strings(fileNames)
+		for _, name := range fileNames {
+			for _, header := range form.File[name] {
+				file, err := header.Open()
+				if err != nil {
+					return "", err
+				}
+				content := sha256.New()
+				_, err = io.Copy(content, file)
+				file.Close()
+				if err != nil {
+					return "", err
+				}
+				fmt.Fprintf(hash, "file %q %q %x\n", name, header.Filename, content.Sum(nil))
+			}
+		}
+		return hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	// 读取请求体计算摘要，再放回供处理函数解析
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return "", err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var parsed interface{}
+	if json.Unmarshal(body, &parsed) == nil {
+		if canonical, err := json.Marshal(parsed); err == nil {
+			body = canonical
+		}
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"lark-record/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 导入文件最大大小
+const maxImportFileSize = 20 << 20
+
+// ImportRecords 导入CSV/TSV/JSONL文件中的数据到数据表
+// 表单参数：file 导入文件，app_token、table_id 目标数据表，
+// format 文件格式（csv/tsv/jsonl，默认按扩展名判断），mapping 列名到字段名的JSON映射，dry_run 只校验不写入
+func ImportRecords(c *gin.Context) {
+	if configService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "配置服务未初始化"})
+		return
+	}
+
+	config := configService.GetConfig()
+	if config.AppID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请先配置飞书应用信息"})
+		return
+	}
+
+	appToken := c.PostForm("app_token")
+	tableID := c.PostForm("table_id")
+	if appToken == "" || tableID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少必要参数"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请上传导入文件"})
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("导入文件不能超过 %d MB", maxImportFileSize>>20)})
+		return
+	}
+
+	format := c.PostForm("format")
+	if format == "" {
+		format = services.ImportFormatFromFilename(fileHeader.Filename)
+	}
+	if format == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无法识别文件格式，请指定 format 为 csv、tsv 或 jsonl"})
+		return
+	}
+
+	var mapping map[string]string
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "列映射格式无效: " + err.Error()})
+			return
+		}
+	}
+
+	dryRun := false
+	if raw := c.PostForm("dry_run"); raw != "" {
+		dryRun, err = strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run 参数无效"})
+			return
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取导入文件失败: " + err.Error()})
+		return
+	}
+	defer file.Close()
+
+	rows, err := services.ParseImportFile(file, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "导入文件中没有数据"})
+		return
+	}
+
+	larkService := serviceManager.GetLarkService(config.AppID, config.AppSecret)
+	report, err := larkService.ImportRecords(appToken, tableID, rows, mapping, dryRun, recordOptions(findTableConfig(config, appToken, tableID)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	logInfo("📥 导入 %s 文件 %s: 共 %d 行，有效 %d 行，写入 %d 行", format, fileHeader.Filename, report.Total, report.Valid, report.Created)
+	c.JSON(http.StatusOK, report)
+}
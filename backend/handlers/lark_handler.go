@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"lark-record/models"
 	"lark-record/services"
 	"net/http"
@@ -27,12 +30,12 @@ func SetConfigService(configSvc *services.ConfigService) {
 	configService = configSvc
 }
 
-// min 辅助函数，返回两个整数中的较小值
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
+// watchService 全局字段检测服务
+var watchService *services.WatchService
+
+// SetWatchService 设置字段检测服务
+func SetWatchService(watchSvc *services.WatchService) {
+	watchService = watchSvc
 }
 
 // 定义日志接口类型
@@ -107,6 +110,47 @@ func SaveConfig(c *gin.Context) {
 		return
 	}
 
+	if newConfig.TimeZone != "" {
+		if _, err := services.LoadFieldLocation(newConfig.TimeZone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的时区: " + newConfig.TimeZone})
+			return
+		}
+	}
+
+	// 校验表格的完成条件和唯一键配置
+	for _, table := range newConfig.Tables {
+		switch table.UniqueKey.OnConflict {
+		case "", models.ConflictReject, models.ConflictUpdate, models.ConflictCreate:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("表格 %s 的重复记录处理方式无效: %s", table.Name, table.UniqueKey.OnConflict)})
+			return
+		}
+
+		for _, field := range table.WriteFields {
+			switch field.OptionPolicy {
+			case "", models.OptionPolicyReject, models.OptionPolicyCreate, models.OptionPolicyOther:
+			default:
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("表格 %s 字段 %s 的选项策略无效: %s", table.Name, field.FieldName, field.OptionPolicy)})
+				return
+			}
+			if !services.IsDefaultTemplate(field.Default) {
+				continue
+			}
+			if err := services.ValidateDefaultTemplate(field.Default); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("表格 %s 字段 %s 的默认值模板无效: %v", table.Name, field.FieldName, err)})
+				return
+			}
+		}
+
+		if table.CompletionRule == nil {
+			continue
+		}
+		if err := services.ValidateConditions(*table.CompletionRule); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("表格 %s 的完成条件无效: %v", table.Name, err)})
+			return
+		}
+	}
+
 	// 测试配置是否有效 - 验证凭证
 	larkService := services.NewLarkService(newConfig.AppID, newConfig.AppSecret)
 	err := larkService.ValidateCredentials()
@@ -260,16 +304,141 @@ func AddRecord(c *gin.Context) {
 	logInfo("- GroupChatID: %s", config.GroupChatID)
 	logInfo("- Tables配置数量: %d", len(config.Tables))
 
+	// 支持JSON请求和带附件文件的multipart请求
 	var req models.AddRecordRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	var uploads map[string][]services.AttachmentUpload
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		var err error
+		req, uploads, err = bindMultipartRecord(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.Fields == nil {
+		req.Fields = make(map[string]interface{})
+	}
+
+	table := findTableConfig(config, req.AppToken, req.TableID)
+
+	// 在提交时计算字段的动态默认值（日期、序号、页面信息等）
+	if table != nil && defaultValueService != nil {
+		ctx := services.DefaultTemplateContext{AppToken: req.AppToken, TableID: req.TableID, Page: req.Page, Now: time.Now(), Location: configService.Location()}
+		sequences, err := defaultValueService.ApplyDefaults(table.WriteFields, req.Fields, ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// 序号在记录写入成功或加入待提交队列后才保存，提交被拒绝或写入失败时归还
+		defer func() {
+			if status := c.Writer.Status(); status >= 200 && status < 300 {
+				defaultValueService.CommitSequences(sequences)
+			} else {
+				defaultValueService.ReleaseSequences(sequences)
+			}
+		}()
+	}
 
 	larkService := serviceManager.GetLarkService(config.AppID, config.AppSecret)
-	recordID, err := larkService.AddRecord(req.AppToken, req.TableID, req.Fields)
+
+	var uniqueKey models.UniqueKeyConfig
+	if table != nil {
+		// 开启严格校验的表格在写入前检查提交内容，附件文件视为已填写对应字段
+		if table.Strict {
+			var required []string
+			for _, field := range table.WriteFields {
+				if field.Required && len(uploads[field.FieldName]) == 0 {
+					required = append(required, field.FieldName)
+				}
+			}
+			if err := validateRecord(larkService, req.AppToken, req.TableID, req.Fields, required, recordOptions(table)); err != nil {
+				// 延后提交的记录在飞书暂时无法访问时仍加入队列，由后台提交前再次校验
+				if !req.Defer || !services.IsRetryableWriteError(err) {
+					status := models.SubmissionFailed
+					if errors.As(err, new(services.FieldValueErrors)) {
+						status = models.SubmissionRejected
+					}
+					recordSubmission(c, nil, req, uploads, models.Submission{Status: status}, err)
+					respondRecordError(c, err)
+					return
+				}
+				logError("⚠️ 暂时无法校验记录，加入待提交队列后再校验: %v", err)
+			}
+		}
+		uniqueKey = table.UniqueKey
+	}
+
+	// 配置了唯一键的表格先查找是否已存在相同记录
+	existingID := ""
+	if len(uniqueKey.Fields) > 0 {
+		var err error
+		existingID, err = larkService.FindRecordByKey(req.AppToken, req.TableID, uniqueKey.Fields, req.Fields)
+		// 无法确认是否重复时不新增记录；延后提交的记录在飞书暂时无法访问时仍加入队列，由后台提交前再次查找
+		if err != nil && (!req.Defer || !services.IsRetryableWriteError(err)) {
+			logError("❌ 查找唯一键相同的记录失败: %v", err)
+			recordSubmission(c, nil, req, uploads, models.Submission{Status: models.SubmissionFailed}, err)
+			if services.IsRetryableWriteError(err) {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": fmt.Sprintf("无法确认是否已存在相同记录，请稍后重试: %v", err)})
+			} else {
+				respondRecordError(c, err)
+			}
+			return
+		}
+	}
+
+	if existingID != "" && uniqueKey.OnConflict != models.ConflictUpdate && uniqueKey.OnConflict != models.ConflictCreate {
+		err := fmt.Errorf("%w（%v 相同）", services.ErrDuplicateRecord, uniqueKey.Fields)
+		recordSubmission(c, nil, req, uploads, models.Submission{Status: models.SubmissionRejected, RecordID: existingID}, err)
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    err.Error(),
+			"recordID": existingID,
+		})
+		return
+	}
+
+	// 客户端要求延后提交时通过校验后加入待提交队列，由后台提交时处理附件和唯一键冲突
+	if req.Defer {
+		if !deferRecord(c, req, uploads, nil) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "待提交队列服务未初始化"})
+		}
+		return
+	}
+
+	// 上传附件并替换为file_token，确认提交不会被拒绝后再上传
+	// 飞书暂时无法访问时，使用上传前的字段和附件文件加入待提交队列
+	submitted := req
+	submitted.Fields = make(map[string]interface{}, len(req.Fields))
+	for name, value := range req.Fields {
+		submitted.Fields[name] = value
+	}
+	if err := larkService.PrepareAttachments(req.AppToken, req.TableID, req.Fields, uploads); err != nil {
+		if !services.IsRetryableWriteError(err) || !deferRecord(c, submitted, uploads, err) {
+			recordSubmission(c, nil, submitted, uploads, models.Submission{Status: models.SubmissionFailed}, err)
+			respondRecordError(c, err)
+		}
+		return
+	}
+
+	if existingID != "" {
+		if uniqueKey.OnConflict == models.ConflictUpdate {
+			upsertRecord(c, larkService, req, existingID, recordOptions(table))
+			return
+		}
+		logInfo("⚠️ 已存在相同唯一键的记录 %s，按配置仍然新增记录", existingID)
+	}
+
+	recordID, err := larkService.AddRecord(req.AppToken, req.TableID, req.Fields, recordOptions(table))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		// 没有收到飞书响应时记录可能已经写入，不加入队列，返回错误由用户确认后再重试
+		err = services.UncertainWriteError(err)
+		// 附件已上传，队列中保存file_token即可
+		if !services.IsRetryableWriteError(err) || !deferRecord(c, req, nil, err) {
+			recordSubmission(c, larkService, req, nil, models.Submission{Status: models.SubmissionFailed}, err)
+			respondRecordError(c, err)
+		}
 		return
 	}
 
@@ -305,221 +474,342 @@ func AddRecord(c *gin.Context) {
 	// }()
 	// }
 
-	// 支持新的多表格配置和旧的单表格配置
-	var checkFields []string
-	var tableName string
-	if len(config.Tables) > 0 {
-		// 新格式：从对应的表格配置中获取检测字段和表格名称
-		for _, table := range config.Tables {
-			if table.AppToken == req.AppToken && table.TableID == req.TableID {
-				checkFields = table.CheckFields
-				tableName = table.Name
-				break
-			}
+	// 持续检测指定字段是否有数据，检测任务会持久化，服务重启后继续检测
+	submission := models.Submission{Status: models.SubmissionCreated, RecordID: recordID}
+	if watchService != nil && watchService.AddWatch(req.AppToken, req.TableID, recordID) != nil {
+		submission.WatchOutcome = models.WatchOutcomeWatching
+	}
+	submissionID := recordSubmission(c, larkService, req, nil, submission, nil)
+
+	response := gin.H{
+		"message":  "记录添加成功",
+		"recordID": recordID,
+	}
+	if submissionID != "" {
+		// 客户端可以使用提交记录ID撤销本次提交
+		response["submissionID"] = submissionID
+	}
+	if existingID != "" {
+		response["duplicateOf"] = existingID
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// respondRecordError 返回写入记录的错误，字段值无效时返回422和逐字段的错误列表
+func respondRecordError(c *gin.Context, err error) {
+	var fieldErrs services.FieldValueErrors
+	if errors.As(err, &fieldErrs) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "提交的字段值无效", "errors": fieldErrs})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidAttachment) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrWriteUncertain) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// findTableConfig 查找数据表的配置，未配置时返回nil
+func findTableConfig(config *models.Config, appToken, tableID string) *models.TableConfig {
+	for i := range config.Tables {
+		if config.Tables[i].AppToken == appToken && config.Tables[i].TableID == tableID {
+			return &config.Tables[i]
 		}
-	} else {
-		// 旧格式：向后兼容
-		checkFields = config.CheckFields
-		tableName = "未命名表格"
-	}
-
-	// 持续检测指定字段是否有数据
-	if checkFields != nil && len(checkFields) > 0 {
-		go func() {
-			fmt.Printf("🔍 开始检测记录ID %s 的字段: %v\n", recordID, checkFields)
-
-			// 等待10秒后开始检测，避免立即检测可能出现的数据同步延迟
-			time.Sleep(10 * time.Second)
-
-			// 设置最大检测次数和基础间隔
-			maxChecks := 20
-			baseInterval := 10 * time.Second
-			maxInterval := 5 * time.Minute
-			checkCount := 0
-
-			// 持续检测，直到所有指定字段都有数据或达到最大检测次数
-			for checkCount < maxChecks {
-				completed, fieldValues, err := larkService.CheckFieldsCompleted(req.AppToken, req.TableID, recordID, checkFields)
-				if err != nil {
-					fmt.Printf("❌ 检查字段状态失败: %v\n", err)
-					
-					// 检查是否是网络错误或飞书API错误，决定是否重试
-					retry := strings.Contains(err.Error(), "network") || strings.Contains(err.Error(), "timeout") || strings.Contains(err.Error(), "API")
-					if !retry {
-						fmt.Printf("❌ 检查字段状态失败，错误不可重试，停止检测\n")
-						break
-					}
-					
-					// 等待一段时间后重试
-					// 计算智能轮询间隔：基础间隔 * (2^min(checkCount, 6))，最大不超过maxInterval
-					exponentialFactor := 1 << uint(min(checkCount, 6)) // 2的幂，最多64倍
-					checkInterval := baseInterval * time.Duration(exponentialFactor)
-					if checkInterval > maxInterval {
-						checkInterval = maxInterval
-					}
-					time.Sleep(checkInterval)
-					checkCount++
-					continue
-				}
+	}
+	return nil
+}
 
-				if completed {
-					// 所有字段都有数据了，打印字段值
-					fmt.Printf("✅ 记录ID %s 的指定字段已全部有数据！\n", recordID)
-					fmt.Printf("📋 字段数据：\n")
-
-					// 准备发送消息的内容，将表格名称放在第一行
-					message := fmt.Sprintf("📊 表格：%s\n\n📢 记录ID %s 的指定字段已全部有数据！\n\n检测字段内容：\n", tableName, recordID)
-					for fieldName, value := range fieldValues {
-						// 处理不同类型的值，确保消息格式清晰
-						switch v := value.(type) {
-						case string:
-							fmt.Printf("  - %s: %s\n", fieldName, v)
-							message += fmt.Sprintf("%s: %s\n", fieldName, v)
-						case []interface{}:
-							// 处理数组类型的值（如多选）
-							fmt.Printf("  - %s: ", fieldName)
-							message += fmt.Sprintf("%s: ", fieldName)
-							for i, item := range v {
-								if i > 0 {
-									fmt.Printf(", ")
-									message += ", "
-								}
-								// 检查是否为用户类型
-								if userMap, ok := item.(map[string]interface{}); ok {
-									// 提取用户信息
-									var userInfo string
-									if enName, ok := userMap["en_name"].(string); ok && enName != "" {
-										userInfo += fmt.Sprintf("en_name:%s", enName)
-									}
-									if id, ok := userMap["id"].(string); ok && id != "" {
-										if userInfo != "" {
-											userInfo += " "
-										}
-										userInfo += fmt.Sprintf("id:%s", id)
-									}
-									if name, ok := userMap["name"].(string); ok && name != "" {
-										if userInfo != "" {
-											userInfo += " "
-										}
-										userInfo += fmt.Sprintf("name:%s", name)
-									}
-									fmt.Printf("%s", userInfo)
-									message += userInfo
-								} else {
-									fmt.Printf("%v", item)
-									message += fmt.Sprintf("%v", item)
-								}
-							}
-							fmt.Printf("\n")
-							message += "\n"
-						case float64:
-							// 尝试将float64值作为时间戳处理
-							// 飞书时间戳通常是毫秒级，且在合理的时间范围内（1970年至今）
-							timestamp := int64(v)
-							if timestamp > 0 && timestamp < 3250368000000 { // 小于2100年的毫秒时间戳
-								// 转换为东八区时间
-								timestampSec := timestamp / 1000 // 转换为秒级时间戳
-								t := time.Unix(timestampSec, 0).In(time.FixedZone("Asia/Shanghai", 8*3600))
-								timeStr := t.Format("2006-01-02 15:04:05")
-								fmt.Printf("  - %s: %s\n", fieldName, timeStr)
-								message += fmt.Sprintf("%s: %s\n", fieldName, timeStr)
-							} else {
-								// 普通数字类型
-								fmt.Printf("  - %s: %v\n", fieldName, v)
-								message += fmt.Sprintf("%s: %v\n", fieldName, v)
-							}
-						case map[string]interface{}:
-							// 处理单个用户类型的值
-							if (fieldName == "记录人" || strings.Contains(fieldName, "人")) || (v["id"] != nil && (v["name"] != nil || v["en_name"] != nil)) {
-								// 提取用户信息
-								var userInfo string
-								if enName, ok := v["en_name"].(string); ok && enName != "" {
-									userInfo += fmt.Sprintf("en_name:%s", enName)
-								}
-								if id, ok := v["id"].(string); ok && id != "" {
-									if userInfo != "" {
-										userInfo += " "
-									}
-									userInfo += fmt.Sprintf("id:%s", id)
-								}
-								if name, ok := v["name"].(string); ok && name != "" {
-									if userInfo != "" {
-										userInfo += " "
-									}
-									userInfo += fmt.Sprintf("name:%s", name)
-								}
-								if userInfo == "" {
-									userInfo = "未知用户"
-								}
-								fmt.Printf("  - %s: %s\n", fieldName, userInfo)
-								message += fmt.Sprintf("%s: %s\n", fieldName, userInfo)
-							} else {
-								// 其他复杂对象，简化显示
-								fmt.Printf("  - %s: %v\n", fieldName, v)
-								message += fmt.Sprintf("%s: [复杂对象]\n", fieldName)
-							}
-						default:
-							fmt.Printf("  - %s: %v\n", fieldName, v)
-							message += fmt.Sprintf("%s: %v\n", fieldName, v)
-						}
-					}
+// recordOptions 按当前配置和数据表的写入字段配置生成写入记录的选项，table为nil时不使用字段配置
+func recordOptions(table *models.TableConfig) services.RecordOptions {
+	opts := configService.RecordOptions()
+	if table != nil {
+		opts.WriteFields = table.WriteFields
+		opts.Strict = table.Strict
+	}
+	return opts
+}
 
-					// 发送消息
-					if config.GroupChatID != "" {
-						err = larkService.SendMessage(config.GroupChatID, message)
-						if err != nil {
-							fmt.Printf("❌ 发送消息失败: %v\n", err)
-						} else {
-							fmt.Printf("✅ 消息发送成功！\n")
-						}
-					}
+// validateRecord 严格校验提交的字段，返回nil表示可以继续写入
+// 返回的错误为校验错误（FieldValueErrors）或获取字段失败的错误，由调用方通过respondRecordError返回
+func validateRecord(larkService *services.LarkService, appToken, tableID string, fields map[string]interface{}, required []string, opts services.RecordOptions) error {
+	fieldErrs, err := larkService.ValidateRecord(appToken, tableID, fields, required, opts)
+	if err != nil {
+		return err
+	}
+	if len(fieldErrs) > 0 {
+		logInfo("⚠️ 记录未通过严格校验: %v", fieldErrs)
+		return fieldErrs
+	}
+	return nil
+}
 
-					// 检查是否需要创建任务
-			for _, table := range config.Tables {
-				if table.AppToken == req.AppToken && table.TableID == req.TableID {
-					// 使用异步方式创建任务，避免阻塞主线程
-						go func(tableConfig models.TableConfig) {
-							fmt.Printf("🔄 开始创建任务...\n")
-							err := larkService.CreateTaskFromFieldValues(tableConfig, fieldValues)
-							if err != nil {
-								fmt.Printf("❌ 创建任务失败: %v\n", err)
-							} else {
-								fmt.Printf("✅ 任务创建成功！\n")
-							}
-						}(table)
-					break
-				}
-			}
+// bindMultipartRecord 解析multipart格式的新增记录请求
+// 表单字段 app_token、table_id、fields（JSON）、page（JSON）、defer，文件的表单名为对应的附件字段名
+func bindMultipartRecord(c *gin.Context) (models.AddRecordRequest, map[string][]services.AttachmentUpload, error) {
+	var req models.AddRecordRequest
 
-					break
-				} else {
-					// 还有字段没有数据，继续检测
-					fmt.Printf("⏳ 记录ID %s 的指定字段尚未全部有数据，继续检测...\n", recordID)
-					// 等待一段时间后重试
-					// 计算智能轮询间隔：基础间隔 * (2^min(checkCount, 6))，最大不超过maxInterval
-					exponentialFactor := 1 << uint(min(checkCount, 6)) // 2的幂，最多64倍
-					checkInterval := baseInterval * time.Duration(exponentialFactor)
-					if checkInterval > maxInterval {
-						checkInterval = maxInterval
-					}
-					time.Sleep(checkInterval)
-					checkCount++
-				}
-			}
+	form, err := c.MultipartForm()
+	if err != nil {
+		return req, nil, fmt.Errorf("解析表单失败: %w", err)
+	}
 
-			// 如果达到最大检测次数仍未完成，记录日志
-			if checkCount >= maxChecks {
-				fmt.Printf("⏰ 记录ID %s 的字段检测已达到最大次数(%d次)，自动停止检测\n", recordID, maxChecks)
+	req.AppToken = c.PostForm("app_token")
+	req.TableID = c.PostForm("table_id")
+	req.Defer = c.PostForm("defer") == "true"
+	if raw := c.PostForm("fields"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &req.Fields); err != nil {
+			return req, nil, fmt.Errorf("fields 格式无效: %w", err)
+		}
+	}
+	if raw := c.PostForm("page"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &req.Page); err != nil {
+			return req, nil, fmt.Errorf("page 格式无效: %w", err)
+		}
+	}
+
+	uploads := make(map[string][]services.AttachmentUpload)
+	for fieldName, headers := range form.File {
+		for _, header := range headers {
+			if header.Size > services.MaxAttachmentSize {
+				return req, nil, fmt.Errorf("文件 '%s' 超过 %d MB", header.Filename, services.MaxAttachmentSize>>20)
 			}
-		}()
+			file, err := header.Open()
+			if err != nil {
+				return req, nil, fmt.Errorf("读取文件 '%s' 失败: %w", header.Filename, err)
+			}
+			data, err := io.ReadAll(file)
+			file.Close()
+			if err != nil {
+				return req, nil, fmt.Errorf("读取文件 '%s' 失败: %w", header.Filename, err)
+			}
+			uploads[fieldName] = append(uploads[fieldName], services.AttachmentUpload{FileName: header.Filename, Data: data})
+		}
+	}
+	return req, uploads, nil
+}
+
+// upsertRecord 使用提交的字段更新唯一键相同的已存在记录
+func upsertRecord(c *gin.Context, larkService *services.LarkService, req models.AddRecordRequest, recordID string, opts services.RecordOptions) {
+	if _, err := larkService.UpdateRecord(req.AppToken, req.TableID, recordID, req.Fields, opts); err != nil {
+		recordSubmission(c, larkService, req, nil, models.Submission{Status: models.SubmissionFailed, RecordID: recordID}, err)
+		respondRecordError(c, err)
+		return
 	}
 
+	// 已在检测中的记录立即重新检测，未检测的记录创建检测任务
+	submission := models.Submission{Status: models.SubmissionUpdated, RecordID: recordID}
+	if watchService != nil {
+		if watchService.HandleRecordChanged(req.AppToken, req.TableID, []string{recordID}) > 0 {
+			submission.WatchOutcome = models.WatchOutcomeWatching
+		} else if watches, _, err := watchService.WatchRecords(req.AppToken, req.TableID, []string{recordID}); err != nil {
+			logInfo("ℹ️ 记录ID %s 未创建检测任务: %v", recordID, err)
+		} else if len(watches) > 0 {
+			submission.WatchOutcome = models.WatchOutcomeWatching
+		}
+	}
+	recordSubmission(c, larkService, req, nil, submission, nil)
+
 	c.JSON(http.StatusOK, gin.H{
-		"message":  "记录添加成功",
+		"message":  "已存在相同的记录，已更新该记录",
 		"recordID": recordID,
+		"updated":  true,
 	})
 }
 
+// UpdateRecord 更新记录，检测中的记录会立即重新检测
+func UpdateRecord(c *gin.Context) {
+	if configService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "配置服务未初始化"})
+		return
+	}
+
+	config := configService.GetConfig()
+	if config.AppID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请先配置飞书应用信息"})
+		return
+	}
+
+	var req models.UpdateRecordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recordID := c.Param("record_id")
+	if req.AppToken == "" || req.TableID == "" || recordID == "" || len(req.Fields) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少必要参数"})
+		return
+	}
+
+	larkService := serviceManager.GetLarkService(config.AppID, config.AppSecret)
+
+	// 更新只提交修改的字段，严格校验时不检查必填字段
+	table := findTableConfig(config, req.AppToken, req.TableID)
+	if table != nil && table.Strict {
+		if err := validateRecord(larkService, req.AppToken, req.TableID, req.Fields, nil, recordOptions(table)); err != nil {
+			respondRecordError(c, err)
+			return
+		}
+	}
+
+	fields, err := larkService.UpdateRecord(req.AppToken, req.TableID, recordID, req.Fields, recordOptions(table))
+	if err != nil {
+		respondRecordError(c, err)
+		return
+	}
+
+	// 修改后的字段可能已满足完成条件，不必等待下一次轮询
+	if watchService != nil {
+		watchService.HandleRecordChanged(req.AppToken, req.TableID, []string{recordID})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "记录更新成功",
+		"recordID": recordID,
+		"fields":   fields,
+	})
+}
+
+// DeleteRecord 删除记录，并取消该记录的检测任务
+func DeleteRecord(c *gin.Context) {
+	if configService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "配置服务未初始化"})
+		return
+	}
+
+	config := configService.GetConfig()
+	if config.AppID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请先配置飞书应用信息"})
+		return
+	}
+
+	appToken := c.Query("app_token")
+	tableID := c.Query("table_id")
+	recordID := c.Param("record_id")
+	if appToken == "" || tableID == "" || recordID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少必要参数"})
+		return
+	}
+
+	larkService := serviceManager.GetLarkService(config.AppID, config.AppSecret)
+	if err := larkService.DeleteRecord(appToken, tableID, recordID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if watchService != nil {
+		watchService.HandleRecordDeleted(appToken, tableID, []string{recordID})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "记录删除成功",
+		"recordID": recordID,
+	})
+}
+
+// BatchAddRecords 批量添加记录，并为新增成功的记录创建检测任务
+func BatchAddRecords(c *gin.Context) {
+	if configService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "配置服务未初始化"})
+		return
+	}
+
+	config := configService.GetConfig()
+	if config.AppID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请先配置飞书应用信息"})
+		return
+	}
+
+	var req models.BatchAddRecordsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.AppToken == "" || req.TableID == "" || len(req.Records) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少必要参数"})
+		return
+	}
+
+	larkService := serviceManager.GetLarkService(config.AppID, config.AppSecret)
+
+	var results []models.BatchRecordResult
+	table := findTableConfig(config, req.AppToken, req.TableID)
+	if table != nil && table.Strict {
+		var err error
+		results, err = batchAddValidRecords(larkService, table, req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		results = larkService.BatchAddRecords(req.AppToken, req.TableID, req.Records, recordOptions(table))
+	}
+
+	var recordIDs []string
+	for _, result := range results {
+		if result.RecordID != "" {
+			recordIDs = append(recordIDs, result.RecordID)
+		}
+	}
+	logInfo("📋 批量添加记录完成: 成功 %d 条，失败 %d 条", len(recordIDs), len(results)-len(recordIDs))
+
+	// 为新增成功的记录统一创建检测任务
+	watching := make(map[string]bool)
+	if watchService != nil && len(recordIDs) > 0 {
+		for _, watch := range watchService.AddWatches(req.AppToken, req.TableID, recordIDs) {
+			watching[watch.RecordID] = true
+		}
+	}
+	recordBatchSubmissions(c, larkService, req, results, watching)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("成功添加 %d 条记录，失败 %d 条", len(recordIDs), len(results)-len(recordIDs)),
+		"results": results,
+	})
+}
+
+// batchAddValidRecords 严格校验每条记录，只写入通过校验的记录，未通过的记录在结果中返回错误
+func batchAddValidRecords(larkService *services.LarkService, table *models.TableConfig, req models.BatchAddRecordsRequest) ([]models.BatchRecordResult, error) {
+	tableFields, err := larkService.GetTableFields(req.AppToken, req.TableID)
+	if err != nil {
+		return nil, fmt.Errorf("获取表格字段失败: %w", err)
+	}
+
+	var required []string
+	for _, field := range table.WriteFields {
+		if field.Required {
+			required = append(required, field.FieldName)
+		}
+	}
+
+	opts := recordOptions(table)
+	results := make([]models.BatchRecordResult, len(req.Records))
+	var valid []map[string]interface{}
+	var indexes []int
+	for i, fields := range req.Records {
+		results[i].Index = i
+		if fieldErrs := services.ValidateRecordFields(tableFields, fields, required, opts); len(fieldErrs) > 0 {
+			results[i].Error = fieldErrs.Error()
+			continue
+		}
+		valid = append(valid, fields)
+		indexes = append(indexes, i)
+	}
+
+	if len(valid) > 0 {
+		for j, result := range larkService.BatchAddRecords(req.AppToken, req.TableID, valid, opts) {
+			result.Index = indexes[j]
+			results[indexes[j]] = result
+		}
+	}
+	return results, nil
+}
+
 // GetAIModels 获取可用的AI模型列表
 func GetAIModels(c *gin.Context) {
 	if configService == nil {
@@ -629,21 +919,21 @@ func CheckRecordStatus(c *gin.Context) {
 	larkService := services.NewLarkService(config.AppID, config.AppSecret)
 
 	// 支持新的多表格配置和旧的单表格配置
-	var checkFields []string
+	var rule models.ConditionGroup
 	if len(config.Tables) > 0 {
-		// 新格式：从对应的表格配置中获取检测字段
+		// 新格式：从对应的表格配置中获取完成条件
 		for _, table := range config.Tables {
 			if table.AppToken == appToken && table.TableID == tableID {
-				checkFields = table.CheckFields
+				rule = services.CompletionRuleForTable(table)
 				break
 			}
 		}
 	} else {
 		// 旧格式：向后兼容
-		checkFields = config.CheckFields
+		rule = services.DefaultCompletionRule(config.CheckFields)
 	}
 
-	completed, _, err := larkService.CheckFieldsCompleted(appToken, tableID, recordID, checkFields)
+	completed, _, err := larkService.CheckRecordCompleted(appToken, tableID, recordID, rule)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
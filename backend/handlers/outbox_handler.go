@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"lark-record/models"
+	"lark-record/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var outboxService *services.OutboxService
+
+// SetOutboxService 设置待提交队列服务
+func SetOutboxService(svc *services.OutboxService) {
+	outboxService = svc
+}
+
+// deferRecord 将新增记录加入待提交队列并返回202
+// cause为写入失败的原因，为nil表示客户端要求延后提交；队列不可用时返回false，由调用方返回原错误
+func deferRecord(c *gin.Context, req models.AddRecordRequest, uploads map[string][]services.AttachmentUpload, cause error) bool {
+	if outboxService == nil {
+		return false
+	}
+
+	item, err := outboxService.Enqueue(req.AppToken, req.TableID, req.Fields, uploads, cause)
+	if err != nil {
+		logError("❌ 加入待提交队列失败: %v", err)
+		return false
+	}
+	submissionID := recordSubmission(c, nil, req, uploads, models.Submission{Status: models.SubmissionQueued, OutboxID: item.ID}, cause)
+
+	message := "记录已加入待提交队列，将在后台提交"
+	if cause != nil {
+		logError("⚠️ 写入记录失败，已加入待提交队列: %v", cause)
+		message = "飞书暂时无法访问，记录已加入待提交队列，将自动重试"
+	}
+	response := gin.H{
+		"message":  message,
+		"outboxID": item.ID,
+		"queued":   true,
+	}
+	if submissionID != "" {
+		response["submissionID"] = submissionID
+	}
+	if cause != nil {
+		response["reason"] = cause.Error()
+	}
+	c.JSON(http.StatusAccepted, response)
+	return true
+}
+
+// ListOutbox 获取待提交队列中的所有记录
+func ListOutbox(c *gin.Context) {
+	if outboxService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "待提交队列服务未初始化"})
+		return
+	}
+
+	c.JSON(http.StatusOK, outboxService.List())
+}
+
+// RetryOutboxItem 立即提交待提交队列中的记录
+func RetryOutboxItem(c *gin.Context) {
+	if outboxService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "待提交队列服务未初始化"})
+		return
+	}
+
+	recordID, err := outboxService.Retry(c.Param("id"))
+	switch {
+	case errors.Is(err, services.ErrOutboxNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrOutboxBusy):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		respondRecordError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "记录提交成功",
+		"recordID": recordID,
+	})
+}
+
+// DiscardOutboxItem 从待提交队列中丢弃记录
+func DiscardOutboxItem(c *gin.Context) {
+	if outboxService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "待提交队列服务未初始化"})
+		return
+	}
+
+	id := c.Param("id")
+	if err := outboxService.Discard(id); err != nil {
+		status := http.StatusConflict
+		if errors.Is(err, services.ErrOutboxNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已丢弃待提交记录", "id": id})
+}
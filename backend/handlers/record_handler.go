@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"lark-record/models"
+	"lark-record/services"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchRecordsRequest 查询记录请求
+type SearchRecordsRequest struct {
+	AppToken string `json:"app_token"`
+	TableID  string `json:"table_id"`
+	models.RecordSearchRequest
+}
+
+// SearchRecords 按条件查询数据表中的记录
+// POST 使用JSON请求体；GET 使用查询参数：
+// field_names、sort 可重复或用逗号分隔，sort 字段名前加 "-" 表示倒序，filter 为JSON格式的条件组合
+func SearchRecords(c *gin.Context) {
+	if configService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "配置服务未初始化"})
+		return
+	}
+
+	config := configService.GetConfig()
+	if config.AppID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请先配置飞书应用信息"})
+		return
+	}
+
+	var req SearchRecordsRequest
+	if c.Request.Method == http.MethodGet {
+		if err := bindSearchQuery(c, &req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.AppToken == "" || req.TableID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少必要参数"})
+		return
+	}
+
+	if req.Filter != nil {
+		if err := services.ValidateConditions(*req.Filter); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "筛选条件无效: " + err.Error()})
+			return
+		}
+	}
+	for _, sort := range req.Sort {
+		if sort.FieldName == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "排序条件缺少字段名"})
+			return
+		}
+	}
+
+	larkService := serviceManager.GetLarkService(config.AppID, config.AppSecret)
+	page, err := larkService.SearchRecords(req.AppToken, req.TableID, req.RecordSearchRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// bindSearchQuery 从查询参数中解析查询记录请求
+func bindSearchQuery(c *gin.Context, req *SearchRecordsRequest) error {
+	req.AppToken = c.Query("app_token")
+	req.TableID = c.Query("table_id")
+	req.ViewID = c.Query("view_id")
+	req.PageToken = c.Query("page_token")
+	req.FieldNames = splitQueryList(c.QueryArray("field_names"))
+
+	if pageSize := c.Query("page_size"); pageSize != "" {
+		size, err := strconv.Atoi(pageSize)
+		if err != nil {
+			return err
+		}
+		req.PageSize = size
+	}
+
+	for _, item := range splitQueryList(c.QueryArray("sort")) {
+		sort := models.RecordSort{FieldName: item}
+		if strings.HasPrefix(item, "-") {
+			sort = models.RecordSort{FieldName: strings.TrimPrefix(item, "-"), Desc: true}
+		}
+		req.Sort = append(req.Sort, sort)
+	}
+
+	if filter := c.Query("filter"); filter != "" {
+		var group models.ConditionGroup
+		if err := json.Unmarshal([]byte(filter), &group); err != nil {
+			return err
+		}
+		req.Filter = &group
+	}
+	return nil
+}
+
+// splitQueryList 展开重复或逗号分隔的查询参数，忽略空值
+func splitQueryList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
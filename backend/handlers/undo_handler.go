@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"lark-record/models"
+	"lark-record/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var undoService *services.UndoService
+
+// SetUndoService 设置撤销服务
+func SetUndoService(svc *services.UndoService) {
+	undoService = svc
+}
+
+// UndoRequest 撤销提交的请求，都不指定时撤销当前客户端最近一次可以撤销的提交
+type UndoRequest struct {
+	SubmissionID string `json:"submission_id"` // 提交记录ID
+	RecordID     string `json:"record_id"`     // 新增的记录ID
+}
+
+// UndoSubmission 撤销一次提交：删除新增的记录、取消字段检测、撤回已发送的通知并删除已创建的任务
+func UndoSubmission(c *gin.Context) {
+	if undoService == nil || historyService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "撤销服务未初始化"})
+		return
+	}
+
+	var req UndoRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	submissionID := req.SubmissionID
+	if submissionID == "" {
+		// 跳过被拒绝、失败和已撤销的提交，撤销最近一次可以撤销的提交
+		query := models.HistoryQuery{RecordID: req.RecordID, Limit: services.MaxHistoryLimit}
+		if req.RecordID == "" {
+			query.Client = c.ClientIP()
+		}
+		items, _ := historyService.Query(query)
+		for _, item := range items {
+			if item.Status == models.SubmissionCreated || item.Status == models.SubmissionQueued {
+				submissionID = item.ID
+				break
+			}
+		}
+		if submissionID == "" && len(items) > 0 {
+			// 没有可以撤销的提交时按最近一次提交返回不能撤销的原因
+			submissionID = items[0].ID
+		}
+		if submissionID == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": services.ErrUndoNotFound.Error()})
+			return
+		}
+	}
+
+	result, err := undoService.Undo(submissionID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrUndoNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrUndoNotAllowed), errors.Is(err, services.ErrUndoAlreadyDone), errors.Is(err, services.ErrUndoExpired):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error(), "submissionID": submissionID})
+		return
+	}
+
+	if !result.Undone {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":        "撤销失败，记录未删除",
+			"submissionID": result.SubmissionID,
+			"recordID":     result.RecordID,
+			"undone":       false,
+			"steps":        result.Steps,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "已撤销提交",
+		"submissionID": result.SubmissionID,
+		"recordID":     result.RecordID,
+		"undone":       true,
+		"steps":        result.Steps,
+	})
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"fmt"
+	"lark-record/models"
+	"lark-record/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 批量创建检测任务默认最多处理的记录数
+const defaultBulkWatchLimit = 500
+
+// RegisterWatchesRequest 为已存在的记录创建检测任务的请求
+type RegisterWatchesRequest struct {
+	AppToken  string   `json:"app_token"`
+	TableID   string   `json:"table_id"`
+	RecordIDs []string `json:"record_ids"`
+}
+
+// BulkRegisterWatchesRequest 按条件批量创建检测任务的请求
+type BulkRegisterWatchesRequest struct {
+	AppToken           string                 `json:"app_token"`
+	TableID            string                 `json:"table_id"`
+	Filter             *models.ConditionGroup `json:"filter"`               // 筛选条件，例如"结论为空"
+	CreatedWithinHours int                    `json:"created_within_hours"` // 只处理最近N小时内创建的记录，0表示不限制
+	Limit              int                    `json:"limit"`                // 最多处理的记录数，默认500
+}
+
+// ListWatches 获取所有未完成的字段检测任务
+func ListWatches(c *gin.Context) {
+	if watchService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "字段检测服务未初始化"})
+		return
+	}
+
+	c.JSON(http.StatusOK, watchService.ListWatches())
+}
+
+// GetWatch 获取指定记录的字段检测任务
+func GetWatch(c *gin.Context) {
+	if watchService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "字段检测服务未初始化"})
+		return
+	}
+
+	recordID := c.Param("record_id")
+	watch, ok := watchService.GetWatch(recordID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未找到该记录的检测任务"})
+		return
+	}
+
+	c.JSON(http.StatusOK, watch)
+}
+
+// CancelWatch 取消指定记录的字段检测任务
+func CancelWatch(c *gin.Context) {
+	if watchService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "字段检测服务未初始化"})
+		return
+	}
+
+	recordID := c.Param("record_id")
+	if !watchService.CancelWatch(recordID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未找到该记录的检测任务"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "检测任务已取消", "recordID": recordID})
+}
+
+// CheckWatchNow 立即重新检测指定记录的字段
+func CheckWatchNow(c *gin.Context) {
+	if watchService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "字段检测服务未初始化"})
+		return
+	}
+
+	recordID := c.Param("record_id")
+	watch, ok := watchService.CheckNow(recordID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未找到该记录的检测任务"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已触发重新检测", "watch": watch})
+}
+
+// RegisterWatches 为指定的已存在记录创建检测任务
+func RegisterWatches(c *gin.Context) {
+	if watchService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "字段检测服务未初始化"})
+		return
+	}
+
+	var req RegisterWatchesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.AppToken == "" || req.TableID == "" || len(req.RecordIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少必要参数"})
+		return
+	}
+
+	added, skipped, err := watchService.WatchRecords(req.AppToken, req.TableID, req.RecordIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("已创建 %d 个检测任务", len(added)),
+		"watches": added,
+		"skipped": skipped,
+	})
+}
+
+// BulkRegisterWatches 按条件查询数据表中的记录并批量创建检测任务
+func BulkRegisterWatches(c *gin.Context) {
+	if watchService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "字段检测服务未初始化"})
+		return
+	}
+
+	var req BulkRegisterWatchesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.AppToken == "" || req.TableID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少必要参数"})
+		return
+	}
+
+	if req.Filter != nil {
+		if err := services.ValidateConditions(*req.Filter); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "筛选条件无效: " + err.Error()})
+			return
+		}
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultBulkWatchLimit
+	}
+
+	createdWithin := time.Duration(req.CreatedWithinHours) * time.Hour
+	added, skipped, err := watchService.WatchMatchingRecords(req.AppToken, req.TableID, req.Filter, createdWithin, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("已创建 %d 个检测任务", len(added)),
+		"watches": added,
+		"skipped": skipped,
+	})
+}
@@ -166,6 +166,7 @@ func (l *Logger) Fatalf(format string, v ...interface{}) {
 var logger *Logger
 var serviceManager *services.ServiceManager
 var configService *services.ConfigService
+var watchService *services.WatchService
 
 func main() {
 	// 初始化日志管理器
@@ -182,6 +183,29 @@ func main() {
 	serviceManager = services.NewServiceManager()
 	// 将服务管理器设置到handlers
 	handlers.SetServiceManager(serviceManager)
+	// 初始化提交记录服务，保存每次提交的内容和结果
+	historyService := services.NewHistoryService("./history.json", configService)
+	handlers.SetHistoryService(historyService)
+	// 初始化字段检测服务，恢复服务重启前未完成的检测任务
+	watchService = services.NewWatchService("./watches.json", configService, serviceManager)
+	watchService.SetHistoryService(historyService)
+	watchService.Start()
+	// 将字段检测服务设置到handlers
+	handlers.SetWatchService(watchService)
+	// 初始化待提交队列，恢复服务重启前未提交的记录
+	outboxService := services.NewOutboxService("./outbox.json", configService, serviceManager, watchService)
+	outboxService.SetHistoryService(historyService)
+	outboxService.Start()
+	handlers.SetOutboxService(outboxService)
+	// 初始化幂等键服务，避免客户端重试时重复创建记录
+	idempotencyService := services.NewIdempotencyService("./idempotency.json", configService)
+	handlers.SetIdempotencyService(idempotencyService)
+	// 初始化撤销服务，用于撤销误提交的记录
+	undoService := services.NewUndoService(configService, serviceManager, watchService, outboxService, historyService)
+	undoService.SetIdempotencyService(idempotencyService)
+	handlers.SetUndoService(undoService)
+	// 初始化默认值服务，加载各数据表的序号
+	handlers.SetDefaultValueService(services.NewDefaultValueService("./sequences.json"))
 
 	// 创建Gin路由
 	r := gin.Default()
@@ -190,7 +214,7 @@ func main() {
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", handlers.IdempotencyKeyHeader}
 	r.Use(cors.New(config))
 
 	// 设置路由
@@ -201,14 +225,44 @@ func main() {
 		api.GET("/config", handlers.GetConfig)
 		api.POST("/config/test", handlers.TestConfig)
 
+		api.POST("/config/defaults/preview", handlers.PreviewDefaults)
+
 		// 多维表格相关
 		api.GET("/bitables", handlers.GetBitables)
 		api.GET("/bitables/tables", handlers.GetBitableTables)
 		api.GET("/bitables/fields", handlers.GetTableFields)
 
 		// 记录操作
-		api.POST("/records", handlers.AddRecord)
+		api.POST("/records", handlers.Idempotent(), handlers.AddRecord)
+		api.POST("/records/batch", handlers.Idempotent(), handlers.BatchAddRecords)
+		api.PUT("/records/:record_id", handlers.UpdateRecord)
+		api.PATCH("/records/:record_id", handlers.UpdateRecord)
+		api.DELETE("/records/:record_id", handlers.DeleteRecord)
 		api.GET("/records/check", handlers.CheckRecordStatus)
+		api.GET("/records/search", handlers.SearchRecords)
+		api.POST("/records/search", handlers.SearchRecords)
+		api.POST("/import", handlers.ImportRecords)
+		api.GET("/export", handlers.ExportRecords)
+
+		// 字段检测任务
+		api.GET("/watches", handlers.ListWatches)
+		api.POST("/watches", handlers.RegisterWatches)
+		api.POST("/watches/bulk", handlers.BulkRegisterWatches)
+		api.GET("/watches/:record_id", handlers.GetWatch)
+		api.DELETE("/watches/:record_id", handlers.CancelWatch)
+		api.POST("/watches/:record_id/check", handlers.CheckWatchNow)
+
+		// 待提交队列
+		api.GET("/outbox", handlers.ListOutbox)
+		api.POST("/outbox/:id/retry", handlers.RetryOutboxItem)
+		api.DELETE("/outbox/:id", handlers.DiscardOutboxItem)
+
+		// 提交记录
+		api.GET("/history", handlers.ListHistory)
+		api.POST("/undo", handlers.UndoSubmission)
+
+		// 飞书事件回调
+		api.POST("/events/lark", handlers.LarkEvent)
 
 		// AI解析
 		api.POST("/ai/parse", handlers.AIParse)
@@ -0,0 +1,34 @@
+package models
+
+// 条件操作符，与飞书多维表格筛选条件的操作符保持一致
+const (
+	OperatorIs             = "is"             // 等于
+	OperatorIsNot          = "isNot"          // 不等于
+	OperatorContains       = "contains"       // 包含
+	OperatorDoesNotContain = "doesNotContain" // 不包含
+	OperatorIsEmpty        = "isEmpty"        // 为空
+	OperatorIsNotEmpty     = "isNotEmpty"     // 不为空
+	OperatorIsGreater      = "isGreater"      // 大于
+	OperatorIsGreaterEqual = "isGreaterEqual" // 大于等于
+	OperatorIsLess         = "isLess"         // 小于
+	OperatorIsLessEqual    = "isLessEqual"    // 小于等于
+)
+
+// 条件组合方式
+const (
+	ConjunctionAnd = "and"
+	ConjunctionOr  = "or"
+)
+
+// Condition 字段条件
+type Condition struct {
+	FieldName string   `json:"field_name"`      // 字段名
+	Operator  string   `json:"operator"`        // 操作符
+	Value     []string `json:"value,omitempty"` // 比较值，isEmpty/isNotEmpty不需要
+}
+
+// ConditionGroup 多个字段条件的组合
+type ConditionGroup struct {
+	Conjunction string      `json:"conjunction"` // 组合方式：and/or，默认and
+	Conditions  []Condition `json:"conditions"`  // 条件列表
+}
@@ -1,10 +1,23 @@
 package models
 
+// 单选/多选字段遇到不在选项列表中的值时的处理方式，未设置时按原样提交
+const (
+	OptionPolicyReject = "reject" // 拒绝提交
+	OptionPolicyCreate = "create" // 写入前在字段中新增该选项
+	OptionPolicyOther  = "other"  // 替换为"其他"选项
+)
+
+// OtherOptionName 选项策略为other时使用的选项名称
+const OtherOptionName = "其他"
+
 // WriteField 待写入字段配置
 type WriteField struct {
-	FieldName string `json:"field_name"` // 字段名
-	Default   string `json:"default"`    // 默认值
-	UiType    string `json:"ui_type"`    // 字段UI类型
+	FieldName          string `json:"field_name"`                     // 字段名
+	Default            string `json:"default"`                        // 默认值
+	UiType             string `json:"ui_type"`                        // 字段UI类型
+	Required           bool   `json:"required,omitempty"`             // 是否必填，开启严格校验时检查
+	CreateMissingLinks bool   `json:"create_missing_links,omitempty"` // 关联字段在关联表中找不到对应记录时自动新建
+	OptionPolicy       string `json:"option_policy,omitempty"`        // 单选/多选字段的新选项处理方式：reject/create/other
 }
 
 // SiliconFlowConfig SiliconFlow API配置
@@ -29,6 +42,15 @@ type Config struct {
 	Tables      []TableConfig     `json:"tables"`        // 多个表格配置
 	GroupChatID string            `json:"group_chat_id"` // 消息发送群ID
 	SiliconFlow SiliconFlowConfig `json:"silicon_flow"`  // SiliconFlow API配置
+	TimeZone    string            `json:"time_zone"`     // 解析日期字段使用的时区，如 Asia/Shanghai，为空时使用服务器本地时区
+
+	IdempotencyWindowHours int `json:"idempotency_window_hours,omitempty"` // 幂等键保留时间（小时），默认24小时
+	HistoryRetentionDays   int `json:"history_retention_days,omitempty"`   // 提交记录保留天数，默认90天
+	UndoWindowMinutes      int `json:"undo_window_minutes,omitempty"`      // 提交后允许撤销的时间（分钟），默认10分钟
+
+	// 事件订阅配置，用于接收多维表格记录变更事件
+	EventVerificationToken string `json:"event_verification_token,omitempty"` // 事件订阅Verification Token
+	EventEncryptKey        string `json:"event_encrypt_key,omitempty"`        // 事件订阅Encrypt Key
 
 	// 向后兼容旧版本配置
 	TableID     string       `json:"table_id,omitempty"`
@@ -46,16 +68,53 @@ type TaskConfig struct {
 	DefaultDueDays    int    `json:"default_due_days"`   // 默认截止天数
 }
 
+// PollingPolicy 字段检测轮询策略，未设置的项使用默认值
+type PollingPolicy struct {
+	InitialDelaySeconds int     `json:"initial_delay_seconds,omitempty"` // 首次检测延迟（秒），默认10秒
+	BaseIntervalSeconds int     `json:"base_interval_seconds,omitempty"` // 基础检测间隔（秒），默认10秒
+	MaxIntervalSeconds  int     `json:"max_interval_seconds,omitempty"`  // 最大检测间隔（秒），默认300秒
+	BackoffFactor       float64 `json:"backoff_factor,omitempty"`        // 检测间隔退避系数，默认2
+	MaxAttempts         int     `json:"max_attempts,omitempty"`          // 最大检测次数，未设置截止时长时默认20次
+	DeadlineSeconds     int     `json:"deadline_seconds,omitempty"`      // 从创建起的最长检测时长（秒），0表示不限制
+}
+
+// TimeoutAction 检测超时（达到最大检测次数或截止时间）后的通知配置
+type TimeoutAction struct {
+	Enabled       bool     `json:"enabled"`                  // 是否发送超时通知
+	ChatID        string   `json:"chat_id,omitempty"`        // 接收通知的群ID，默认使用GroupChatID
+	MentionFields []string `json:"mention_fields,omitempty"` // 需要@的人员字段
+}
+
+// 唯一键重复时的处理方式
+const (
+	ConflictReject = "reject" // 拒绝提交并返回已存在的记录ID（默认）
+	ConflictUpdate = "update" // 使用提交的字段更新已存在的记录
+	ConflictCreate = "create" // 仍然新增记录，并在响应中标明重复的记录ID
+)
+
+// UniqueKeyConfig 记录唯一键配置
+// 唯一键字段建议使用文本、数字、单选等可精确匹配的字段
+type UniqueKeyConfig struct {
+	Fields     []string `json:"fields"`      // 组成唯一键的字段，为空时不检查重复
+	OnConflict string   `json:"on_conflict"` // 已存在相同记录时的处理方式: reject / update / create
+}
+
 // TableConfig 单个表格的配置
 type TableConfig struct {
-	URL               string        `json:"url"`                 // 飞书多维表格URL
-	AppToken          string        `json:"app_token"`           // 从URL解析的app_token
-	TableID           string        `json:"table_id"`            // 数据表ID
-	Name              string        `json:"name"`                // 表格名称
-	WriteFields       []WriteField  `json:"write_fields"`        // 待写入的字段
-	CheckFields       []string      `json:"check_fields"`        // 需要检测是否有值的字段
-	Task              TaskConfig    `json:"task"`                // 任务配置
-	AIParse           AIParseConfig `json:"ai_parse"`            // AI解析配置
+	URL            string          `json:"url"`                       // 飞书多维表格URL
+	AppToken       string          `json:"app_token"`                 // 从URL解析的app_token
+	TableID        string          `json:"table_id"`                  // 数据表ID
+	Name           string          `json:"name"`                      // 表格名称
+	WriteFields    []WriteField    `json:"write_fields"`              // 待写入的字段
+	CheckFields    []string        `json:"check_fields"`              // 需要检测是否有值的字段
+	CompletionRule *ConditionGroup `json:"completion_rule,omitempty"` // 完成条件，未设置时要求检测字段全部有值
+	Task           TaskConfig      `json:"task"`                      // 任务配置
+	AIParse        AIParseConfig   `json:"ai_parse"`                  // AI解析配置
+	Polling        PollingPolicy   `json:"polling"`                   // 字段检测轮询策略
+	TimeoutAction  TimeoutAction   `json:"timeout_action"`            // 检测超时通知
+	ProgressNotify bool            `json:"progress_notify"`           // 检测字段逐个填写时是否发送进度通知
+	UniqueKey      UniqueKeyConfig `json:"unique_key"`                // 记录唯一键，用于避免重复提交
+	Strict         bool            `json:"strict_validation"`         // 写入前严格校验字段，不通过时返回422
 
 	// 向后兼容旧版本配置
 	CreateTask        bool   `json:"create_task,omitempty"`         // 是否创建任务
@@ -79,16 +138,45 @@ type TableInfo struct {
 
 // Field 表格字段
 type Field struct {
-	FieldName string `json:"field_name"`
-	FieldType string `json:"field_type"`
-	FieldID   string `json:"field_id"`
-	IsPrimary bool   `json:"is_primary"`
-	UiType    string `json:"ui_type"`
+	FieldName   string   `json:"field_name"`
+	FieldType   string   `json:"field_type"`
+	FieldID     string   `json:"field_id"`
+	IsPrimary   bool     `json:"is_primary"`
+	UiType      string   `json:"ui_type"`
+	Options     []string `json:"options,omitempty"`       // 单选/多选字段的选项
+	LinkTableID string   `json:"link_table_id,omitempty"` // 关联字段关联的数据表ID
 }
 
 // Record 记录数据
 type Record struct {
-	Fields map[string]interface{} `json:"fields"`
+	RecordID         string                 `json:"record_id,omitempty"`
+	Fields           map[string]interface{} `json:"fields"`
+	CreatedTime      int64                  `json:"created_time,omitempty"`       // 创建时间（毫秒时间戳）
+	LastModifiedTime int64                  `json:"last_modified_time,omitempty"` // 最后更新时间（毫秒时间戳）
+}
+
+// RecordSort 记录排序条件
+type RecordSort struct {
+	FieldName string `json:"field_name"`     // 排序字段
+	Desc      bool   `json:"desc,omitempty"` // 是否倒序
+}
+
+// RecordSearchRequest 记录查询请求
+type RecordSearchRequest struct {
+	ViewID     string          `json:"view_id,omitempty"`     // 视图ID，指定后按视图的筛选和排序查询
+	FieldNames []string        `json:"field_names,omitempty"` // 只返回指定字段，为空时返回全部字段
+	Filter     *ConditionGroup `json:"filter,omitempty"`      // 筛选条件
+	Sort       []RecordSort    `json:"sort,omitempty"`        // 排序条件
+	PageSize   int             `json:"page_size,omitempty"`   // 每页数量，最大500
+	PageToken  string          `json:"page_token,omitempty"`  // 分页标记
+}
+
+// RecordPage 分页的记录查询结果
+type RecordPage struct {
+	Items     []Record `json:"items"`
+	Total     int      `json:"total"`
+	HasMore   bool     `json:"has_more"`
+	PageToken string   `json:"page_token,omitempty"`
 }
 
 // AddRecordRequest 新增记录请求
@@ -96,6 +184,35 @@ type AddRecordRequest struct {
 	AppToken string                 `json:"app_token"`
 	TableID  string                 `json:"table_id"`
 	Fields   map[string]interface{} `json:"fields"`
+	Defer    bool                   `json:"defer,omitempty"` // 不立即写入，加入待提交队列由后台提交
+	Page     PageContext            `json:"page"`            // 提交时所在的页面，用于默认值模板
+}
+
+// PageContext 提交记录时浏览器当前页面的信息
+type PageContext struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+}
+
+// UpdateRecordRequest 更新记录请求，只修改请求中提供的字段
+type UpdateRecordRequest struct {
+	AppToken string                 `json:"app_token"`
+	TableID  string                 `json:"table_id"`
+	Fields   map[string]interface{} `json:"fields"`
+}
+
+// BatchAddRecordsRequest 批量新增记录请求，所有记录写入同一个数据表
+type BatchAddRecordsRequest struct {
+	AppToken string                   `json:"app_token"`
+	TableID  string                   `json:"table_id"`
+	Records  []map[string]interface{} `json:"records"` // 每条记录的字段值
+}
+
+// BatchRecordResult 批量新增中单条记录的结果
+type BatchRecordResult struct {
+	Index    int    `json:"index"`               // 记录在请求中的序号
+	RecordID string `json:"record_id,omitempty"` // 新增成功时的记录ID
+	Error    string `json:"error,omitempty"`     // 新增失败时的错误信息
 }
 
 // SendMessageRequest 发送消息请求
@@ -0,0 +1,99 @@
+package models
+
+import "time"
+
+// 提交记录的状态
+const (
+	SubmissionCreated  = "created"  // 已新增记录
+	SubmissionUpdated  = "updated"  // 唯一键重复，已更新已存在的记录
+	SubmissionQueued   = "queued"   // 已加入待提交队列，等待后台提交
+	SubmissionRejected = "rejected" // 提交内容未通过校验或与已有记录重复，未写入
+	SubmissionFailed   = "failed"   // 写入失败
+	SubmissionUndone   = "undone"   // 已撤销，新增的记录已删除
+)
+
+// 字段检测的结果
+const (
+	WatchOutcomeWatching  = "watching"  // 检测中
+	WatchOutcomeCompleted = "completed" // 已满足完成条件
+	WatchOutcomeStalled   = "stalled"   // 检测超时，已停止检测
+	WatchOutcomeCancelled = "cancelled" // 已取消检测或记录已删除
+	WatchOutcomeMissing   = "missing"   // 检测时记录已不存在或无权访问，已停止检测
+	WatchOutcomeFailed    = "failed"    // 检测出错，已停止检测
+)
+
+// 通知、任务和撤销步骤的执行结果
+const (
+	ActionSucceeded = "succeeded"
+	ActionFailed    = "failed"
+	ActionSkipped   = "skipped" // 撤销时无需执行该步骤
+)
+
+// 撤销提交的步骤
+const (
+	UndoStepDiscardOutbox = "discard_outbox" // 从待提交队列中删除
+	UndoStepDeleteRecord  = "delete_record"  // 删除新增的记录
+	UndoStepCancelWatch   = "cancel_watch"   // 取消字段检测
+	UndoStepRecallMessage = "recall_message" // 撤回已发送的通知消息
+	UndoStepDeleteTask    = "delete_task"    // 删除已创建的任务
+)
+
+// ActionResult 完成通知或任务创建的执行结果
+type ActionResult struct {
+	Status string    `json:"status"`          // succeeded 或 failed
+	ID     string    `json:"id,omitempty"`    // 消息ID或任务GUID，用于撤销
+	Error  string    `json:"error,omitempty"` // 失败原因
+	Time   time.Time `json:"time"`            // 执行时间
+}
+
+// Submission 一次记录提交的本地审计日志
+type Submission struct {
+	ID               string                 `json:"id"`                          // 日志ID
+	Time             time.Time              `json:"time"`                        // 提交时间
+	UpdatedAt        time.Time              `json:"updated_at"`                  // 最后更新时间（检测结果、通知等）
+	Client           string                 `json:"client"`                      // 客户端IP
+	UserAgent        string                 `json:"user_agent,omitempty"`        // 客户端User-Agent
+	AppToken         string                 `json:"app_token"`                   // 多维表格app_token
+	TableID          string                 `json:"table_id"`                    // 数据表ID
+	TableName        string                 `json:"table_name,omitempty"`        // 表格名称
+	Fields           map[string]interface{} `json:"fields"`                      // 按字段类型编码后的字段值，附件只保留文件名
+	RecordID         string                 `json:"record_id,omitempty"`         // 记录ID
+	Status           string                 `json:"status"`                      // 提交状态
+	LarkCode         int                    `json:"lark_code"`                   // 飞书接口返回码，0表示成功，-1表示未收到飞书的响应
+	Error            string                 `json:"error,omitempty"`             // 错误信息
+	OutboxID         string                 `json:"outbox_id,omitempty"`         // 待提交队列项ID
+	IdempotencyKey   string                 `json:"idempotency_key,omitempty"`   // 新增请求的幂等键（含请求方法和路径），撤销后失效
+	WatchOutcome     string                 `json:"watch_outcome,omitempty"`     // 字段检测结果
+	Notification     *ActionResult          `json:"notification,omitempty"`      // 完成或超时通知的发送结果
+	Task             *ActionResult          `json:"task,omitempty"`              // 任务的创建结果
+	ProgressMessages []string               `json:"progress_messages,omitempty"` // 已发送的字段进度通知的消息ID
+	UndoneAt         *time.Time             `json:"undone_at,omitempty"`         // 撤销时间
+}
+
+// HistoryQuery 提交记录查询条件，零值表示不限制
+type HistoryQuery struct {
+	AppToken string
+	TableID  string
+	Status   string
+	RecordID string
+	Client   string
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
+
+// UndoStep 撤销提交的一个步骤的执行结果
+type UndoStep struct {
+	Step   string `json:"step"`            // 步骤
+	Status string `json:"status"`          // succeeded、failed 或 skipped
+	ID     string `json:"id,omitempty"`    // 消息ID、任务GUID或队列项ID
+	Error  string `json:"error,omitempty"` // 失败原因或跳过的原因
+}
+
+// UndoResult 撤销提交的结果
+type UndoResult struct {
+	SubmissionID string     `json:"submissionID"`
+	RecordID     string     `json:"recordID,omitempty"`
+	Undone       bool       `json:"undone"` // 记录是否已删除（或已从待提交队列中删除）
+	Steps        []UndoStep `json:"steps"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// IdempotencyEntry 幂等键对应的原始请求结果，重复请求直接返回该结果
+type IdempotencyEntry struct {
+	Key         string          `json:"key"`         // 请求方法、路径和幂等键
+	Fingerprint string          `json:"fingerprint"` // 请求体摘要，用于识别幂等键被用于不同的请求
+	Status      int             `json:"status"`      // 原响应状态码
+	Body        json.RawMessage `json:"body"`        // 原响应内容
+	CreatedAt   time.Time       `json:"created_at"`  // 首次请求时间
+}
@@ -0,0 +1,19 @@
+package models
+
+// ImportRowError 导入时单行数据的错误
+type ImportRowError struct {
+	Row   int    `json:"row"`             // 数据在文件中的行号
+	Field string `json:"field,omitempty"` // 出错的字段，为空表示整行错误
+	Error string `json:"error"`           // 错误信息
+}
+
+// ImportReport 导入结果报告
+type ImportReport struct {
+	DryRun    bool             `json:"dry_run"`    // 是否只校验不写入
+	Total     int              `json:"total"`      // 文件中的数据行数
+	Valid     int              `json:"valid"`      // 校验通过的行数
+	Created   int              `json:"created"`    // 成功写入的行数
+	Failed    int              `json:"failed"`     // 校验或写入失败的行数
+	RecordIDs []string         `json:"record_ids"` // 新增记录的ID
+	Errors    []ImportRowError `json:"errors"`     // 每行的错误
+}
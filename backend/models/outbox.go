@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// 待提交记录的状态
+const (
+	OutboxPending = "pending" // 等待后台重试提交
+	OutboxFailed  = "failed"  // 多次提交失败或提交内容无效，需要手动重试或丢弃
+)
+
+// OutboxItem 暂时无法写入飞书的记录提交，保存在本地待提交队列中
+type OutboxItem struct {
+	ID            string                 `json:"id"`                   // 队列项ID
+	AppToken      string                 `json:"app_token"`            // 多维表格app_token
+	TableID       string                 `json:"table_id"`             // 数据表ID
+	Fields        map[string]interface{} `json:"fields"`               // 待写入的字段，附件以base64保存
+	Status        string                 `json:"status"`               // 状态
+	Attempts      int                    `json:"attempts"`             // 已尝试提交次数
+	NextAttemptAt time.Time              `json:"next_attempt_at"`      // 下次提交时间
+	LastError     string                 `json:"last_error,omitempty"` // 最近一次提交错误
+	CreatedAt     time.Time              `json:"created_at"`           // 加入队列时间
+}
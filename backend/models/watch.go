@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Watch 记录字段完成检测任务
+type Watch struct {
+	RecordID     string         `json:"record_id"`               // 记录ID
+	AppToken     string         `json:"app_token"`               // 多维表格app_token
+	TableID      string         `json:"table_id"`                // 数据表ID
+	TableName    string         `json:"table_name"`              // 表格名称
+	CheckFields  []string       `json:"check_fields"`            // 需要检测是否有值的字段
+	Rule         ConditionGroup `json:"rule"`                    // 完成条件
+	Progress     bool           `json:"progress"`                // 是否发送字段填写进度通知
+	FilledFields []string       `json:"filled_fields,omitempty"` // 已通知过的已满足条件的字段
+	Policy       PollingPolicy  `json:"policy"`                  // 创建时生效的轮询策略
+	Attempts     int            `json:"attempts"`                // 已检测次数
+	Triggered    bool           `json:"triggered,omitempty"`     // 下次检测由事件或手动触发，不计入检测次数
+	NextCheckAt  time.Time      `json:"next_check_at"`           // 下次检测时间
+	Deadline     *time.Time     `json:"deadline,omitempty"`      // 检测截止时间
+	LastError    string         `json:"last_error,omitempty"`    // 最近一次检测错误
+	CreatedAt    time.Time      `json:"created_at"`              // 创建时间
+}
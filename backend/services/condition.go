@@ -0,0 +1,290 @@
+package services
+
+import (
+	"fmt"
+	"lark-record/models"
+	"strconv"
+	"strings"
+)
+
+// DefaultCompletionRule 默认完成条件：所有检测字段都有值
+func DefaultCompletionRule(checkFields []string) models.ConditionGroup {
+	rule := models.ConditionGroup{Conjunction: models.ConjunctionAnd}
+	for _, fieldName := range checkFields {
+		rule.Conditions = append(rule.Conditions, models.Condition{
+			FieldName: fieldName,
+			Operator:  models.OperatorIsNotEmpty,
+		})
+	}
+	return rule
+}
+
+// CompletionRuleForTable 获取表格的完成条件，未配置时使用检测字段生成默认条件
+func CompletionRuleForTable(table models.TableConfig) models.ConditionGroup {
+	if table.CompletionRule != nil && len(table.CompletionRule.Conditions) > 0 {
+		return *table.CompletionRule
+	}
+	return DefaultCompletionRule(table.CheckFields)
+}
+
+// ConditionFieldNames 返回条件涉及的字段名（去重，保持顺序）
+func ConditionFieldNames(group models.ConditionGroup) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, cond := range group.Conditions {
+		if !seen[cond.FieldName] {
+			seen[cond.FieldName] = true
+			names = append(names, cond.FieldName)
+		}
+	}
+	return names
+}
+
+// EvaluateCompletion 判断记录是否满足完成条件，并返回条件涉及字段中已有值的字段
+// 没有任何条件时视为已完成，与只检测字段列表时的行为一致
+func EvaluateCompletion(rule models.ConditionGroup, fields map[string]interface{}) (bool, map[string]interface{}) {
+	fieldValues := make(map[string]interface{})
+	for _, fieldName := range ConditionFieldNames(rule) {
+		if value := fields[fieldName]; !isEmptyFieldValue(value) {
+			fieldValues[fieldName] = value
+		}
+	}
+
+	completed := len(rule.Conditions) == 0 || EvaluateConditions(rule, fields)
+	return completed, fieldValues
+}
+
+// SatisfiedFields 返回条件涉及字段中已满足该字段所有条件的字段（按条件顺序）
+// 用于进度通知：只检测是否有值时即已填写的字段，配置了条件时为字段值已满足条件的字段
+func SatisfiedFields(rule models.ConditionGroup, fields map[string]interface{}) []string {
+	unmet := make(map[string]bool)
+	for _, cond := range rule.Conditions {
+		if !evaluateCondition(cond, fields[cond.FieldName]) {
+			unmet[cond.FieldName] = true
+		}
+	}
+
+	var satisfied []string
+	for _, fieldName := range ConditionFieldNames(rule) {
+		if !unmet[fieldName] {
+			satisfied = append(satisfied, fieldName)
+		}
+	}
+	return satisfied
+}
+
+// EvaluateConditions 判断记录字段是否满足条件组合
+// 没有任何条件时视为不满足，避免误判为已完成
+func EvaluateConditions(group models.ConditionGroup, fields map[string]interface{}) bool {
+	if len(group.Conditions) == 0 {
+		return false
+	}
+
+	isOr := strings.EqualFold(group.Conjunction, models.ConjunctionOr)
+	for _, cond := range group.Conditions {
+		matched := evaluateCondition(cond, fields[cond.FieldName])
+		if isOr && matched {
+			return true
+		}
+		if !isOr && !matched {
+			return false
+		}
+	}
+	return !isOr
+}
+
+// ValidateConditions 检查条件组合的操作符和比较值是否有效
+func ValidateConditions(group models.ConditionGroup) error {
+	if group.Conjunction != "" && !strings.EqualFold(group.Conjunction, models.ConjunctionAnd) && !strings.EqualFold(group.Conjunction, models.ConjunctionOr) {
+		return fmt.Errorf("不支持的条件组合方式: %s", group.Conjunction)
+	}
+
+	for _, cond := range group.Conditions {
+		if cond.FieldName == "" {
+			return fmt.Errorf("条件缺少字段名")
+		}
+		switch cond.Operator {
+		case models.OperatorIsEmpty, models.OperatorIsNotEmpty:
+		case models.OperatorIs, models.OperatorIsNot, models.OperatorContains, models.OperatorDoesNotContain:
+			if len(cond.Value) == 0 {
+				return fmt.Errorf("字段 '%s' 的条件 %s 缺少比较值", cond.FieldName, cond.Operator)
+			}
+		case models.OperatorIsGreater, models.OperatorIsGreaterEqual, models.OperatorIsLess, models.OperatorIsLessEqual:
+			if len(cond.Value) == 0 {
+				return fmt.Errorf("字段 '%s' 的条件 %s 缺少比较值", cond.FieldName, cond.Operator)
+			}
+			if _, err := strconv.ParseFloat(cond.Value[0], 64); err != nil {
+				return fmt.Errorf("字段 '%s' 的条件 %s 比较值不是数字: %s", cond.FieldName, cond.Operator, cond.Value[0])
+			}
+		default:
+			return fmt.Errorf("字段 '%s' 使用了不支持的操作符: %s", cond.FieldName, cond.Operator)
+		}
+	}
+	return nil
+}
+
+// evaluateCondition 判断单个字段值是否满足条件
+func evaluateCondition(cond models.Condition, value interface{}) bool {
+	switch cond.Operator {
+	case models.OperatorIsEmpty:
+		return isEmptyFieldValue(value)
+	case models.OperatorIsNotEmpty:
+		return !isEmptyFieldValue(value)
+	}
+
+	if isEmptyFieldValue(value) {
+		// 空值只满足"不等于"和"不包含"
+		return cond.Operator == models.OperatorIsNot || cond.Operator == models.OperatorDoesNotContain
+	}
+
+	texts := fieldValueTexts(value)
+	switch cond.Operator {
+	case models.OperatorIs:
+		return anyTextMatches(texts, cond.Value, func(text, expected string) bool { return text == expected })
+	case models.OperatorIsNot:
+		return !anyTextMatches(texts, cond.Value, func(text, expected string) bool { return text == expected })
+	case models.OperatorContains:
+		return anyTextMatches(texts, cond.Value, strings.Contains)
+	case models.OperatorDoesNotContain:
+		return !anyTextMatches(texts, cond.Value, strings.Contains)
+	case models.OperatorIsGreater, models.OperatorIsGreaterEqual, models.OperatorIsLess, models.OperatorIsLessEqual:
+		if len(cond.Value) == 0 {
+			return false
+		}
+		expected, err := strconv.ParseFloat(cond.Value[0], 64)
+		if err != nil {
+			return false
+		}
+		actual, ok := fieldValueNumber(value)
+		if !ok {
+			return false
+		}
+		switch cond.Operator {
+		case models.OperatorIsGreater:
+			return actual > expected
+		case models.OperatorIsGreaterEqual:
+			return actual >= expected
+		case models.OperatorIsLess:
+			return actual < expected
+		default:
+			return actual <= expected
+		}
+	}
+	return false
+}
+
+// anyTextMatches 判断字段文本中是否有任意一个与任意比较值匹配
+func anyTextMatches(texts, expected []string, match func(text, expected string) bool) bool {
+	for _, text := range texts {
+		for _, e := range expected {
+			if match(text, e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isEmptyFieldValue 判断字段值是否为空
+func isEmptyFieldValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// fieldValueTexts 将字段值转换为用于比较的文本列表
+// 文本字段的富文本片段会拼接为一个文本，多选、人员等数组字段每个元素一个文本
+func fieldValueTexts(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case bool:
+		return []string{strconv.FormatBool(v)}
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	case map[string]interface{}:
+		// 公式、查找引用等字段的值包在value中
+		if inner, ok := v["value"]; ok {
+			return fieldValueTexts(inner)
+		}
+		return []string{fieldItemText(v)}
+	case []interface{}:
+		// 富文本片段拼接为一个文本
+		if isRichText(v) {
+			var b strings.Builder
+			for _, item := range v {
+				b.WriteString(fieldItemText(item.(map[string]interface{})))
+			}
+			return []string{b.String()}
+		}
+		var texts []string
+		for _, item := range v {
+			if m, ok := item.(map[string]interface{}); ok {
+				texts = append(texts, fieldItemText(m))
+			} else {
+				texts = append(texts, fieldValueTexts(item)...)
+			}
+		}
+		return texts
+	}
+	return []string{fmt.Sprintf("%v", value)}
+}
+
+// fieldItemText 提取复杂字段元素的显示文本
+func fieldItemText(item map[string]interface{}) string {
+	for _, key := range []string{"text", "name", "en_name", "link", "id"} {
+		if text, ok := item[key].(string); ok && text != "" {
+			return text
+		}
+	}
+	return fmt.Sprintf("%v", item)
+}
+
+// isRichText 判断数组是否为文本字段的富文本片段
+func isRichText(items []interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := m["text"]; !ok {
+			return false
+		}
+		if _, ok := m["type"]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldValueNumber 将字段值转换为数字，支持数字、数字文本和公式结果
+func fieldValueNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	case map[string]interface{}:
+		if inner, ok := v["value"]; ok {
+			return fieldValueNumber(inner)
+		}
+	case []interface{}:
+		texts := fieldValueTexts(v)
+		if len(texts) == 1 {
+			return fieldValueNumber(texts[0])
+		}
+	}
+	return 0, false
+}
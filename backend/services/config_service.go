@@ -6,12 +6,14 @@ import (
 	"lark-record/models"
 	"os"
 	"sync"
+	"time"
 )
 
 // ConfigService 配置管理服务
 type ConfigService struct {
 	configPath string
 	config     *models.Config
+	location   *time.Location // 按配置的时区加载，解析和显示日期字段使用
 	mutex      sync.RWMutex
 }
 
@@ -24,6 +26,7 @@ func NewConfigService(configPath string) *ConfigService {
 	service := &ConfigService{
 		configPath: configPath,
 		config:     &models.Config{},
+		location:   time.Local,
 	}
 
 	// 初始化时加载配置
@@ -42,6 +45,19 @@ func (s *ConfigService) GetConfig() *models.Config {
 	return &configCopy
 }
 
+// Location 获取配置的时区，未配置或配置无效时返回服务器本地时区
+func (s *ConfigService) Location() *time.Location {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.location
+}
+
+// RecordOptions 获取按当前配置写入记录的选项，不包含数据表的写入字段配置
+func (s *ConfigService) RecordOptions() RecordOptions {
+	return RecordOptions{Location: s.Location()}
+}
+
 // SetConfig 设置配置
 func (s *ConfigService) SetConfig(config *models.Config) error {
 	s.mutex.Lock()
@@ -70,6 +86,27 @@ func (s *ConfigService) UpdateConfig(newConfig *models.Config) error {
 		s.config.GroupChatID = newConfig.GroupChatID
 	}
 
+	// 更新事件订阅配置
+	if newConfig.EventVerificationToken != "" {
+		s.config.EventVerificationToken = newConfig.EventVerificationToken
+	}
+	if newConfig.EventEncryptKey != "" {
+		s.config.EventEncryptKey = newConfig.EventEncryptKey
+	}
+
+	if newConfig.TimeZone != "" {
+		s.config.TimeZone = newConfig.TimeZone
+	}
+	if newConfig.IdempotencyWindowHours > 0 {
+		s.config.IdempotencyWindowHours = newConfig.IdempotencyWindowHours
+	}
+	if newConfig.HistoryRetentionDays > 0 {
+		s.config.HistoryRetentionDays = newConfig.HistoryRetentionDays
+	}
+	if newConfig.UndoWindowMinutes > 0 {
+		s.config.UndoWindowMinutes = newConfig.UndoWindowMinutes
+	}
+
 	// 更新SiliconFlow配置
 	if newConfig.SiliconFlow.ApiKey != "" {
 		s.config.SiliconFlow = newConfig.SiliconFlow
@@ -135,6 +172,8 @@ func (s *ConfigService) loadConfig() {
 		return
 	}
 
+	s.updateLocation()
+
 	logInfo("配置文件加载成功")
 }
 
@@ -151,6 +190,18 @@ func (s *ConfigService) saveConfig() error {
 		return fmt.Errorf("写入配置文件失败: %v", err)
 	}
 
+	s.updateLocation()
+
 	logInfo("配置已保存到文件")
 	return nil
+}
+
+// updateLocation 按配置的时区更新location，调用方需持有写锁
+func (s *ConfigService) updateLocation() {
+	loc, err := LoadFieldLocation(s.config.TimeZone)
+	if err != nil {
+		logError("加载时区失败，使用服务器本地时区: %v", err)
+		loc = time.Local
+	}
+	s.location = loc
 }
\ No newline at end of file
@@ -0,0 +1,409 @@
+package services
+
+import (
+	"crypto/rand"
+	"fmt"
+	"lark-record/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// 默认值模板中时间的默认输出格式
+const (
+	templateNowLayout   = "2006-01-02 15:04"
+	templateTodayLayout = "2006-01-02"
+)
+
+// DefaultTemplateContext 默认值模板的求值环境
+type DefaultTemplateContext struct {
+	AppToken string
+	TableID  string
+	Page     models.PageContext
+	Now      time.Time
+	Location *time.Location // now、today使用的时区，为nil时使用服务器本地时区
+	Preview  bool           // 预览时不递增序号，只显示下一个序号
+}
+
+// IsDefaultTemplate 判断默认值是否包含模板表达式
+func IsDefaultTemplate(value string) bool {
+	return strings.Contains(value, "{{")
+}
+
+// ValidateDefaultTemplate 检查默认值模板的语法，不会递增序号
+func ValidateDefaultTemplate(tmpl string) error {
+	_, err := renderDefaultTemplate(tmpl, DefaultTemplateContext{Now: time.Now(), Preview: true}, func(bool) int64 { return 1 })
+	return err
+}
+
+// DefaultValueService 在提交时计算字段的动态默认值
+// 支持 {{now | date "2006-01-02"}}、{{today+3d}}、{{seq "BUG-%04d"}}、{{uuid}}、{{page.url}}、{{page.title}}，
+// 每个数据表的序号持久化到本地文件
+type DefaultValueService struct {
+	mu        sync.Mutex
+	path      string
+	sequences map[string]int64 // key: appToken/tableID，值为最后一次写入成功的序号
+	reserved  map[string]int64 // 已预留但尚未写入成功的最大序号，不持久化
+}
+
+// SequenceReservation 一次提交中预留的序号
+// 记录写入成功后调用CommitSequences保存，提交被拒绝或写入失败时调用ReleaseSequences归还
+type SequenceReservation struct {
+	key     string
+	numbers []int64
+}
+
+// NewDefaultValueService 创建默认值服务并加载序号
+func NewDefaultValueService(path string) *DefaultValueService {
+	if path == "" {
+		path = "./sequences.json"
+	}
+	s := &DefaultValueService{
+		path:      path,
+		sequences: make(map[string]int64),
+		reserved:  make(map[string]int64),
+	}
+	if _, err := readJSONFile(path, &s.sequences); err != nil {
+		logError("加载序号失败: %v", err)
+	}
+	return s
+}
+
+// ApplyDefaults 为未填写或保持模板原文的字段填入默认值模板的计算结果
+// 模板中的序号只是预留，调用方需要在写入成功后调用CommitSequences，否则调用ReleaseSequences
+func (s *DefaultValueService) ApplyDefaults(writeFields []models.WriteField, fields map[string]interface{}, ctx DefaultTemplateContext) (*SequenceReservation, error) {
+	reservation := &SequenceReservation{key: sequenceKey(ctx)}
+	for _, field := range writeFields {
+		if !IsDefaultTemplate(field.Default) {
+			continue
+		}
+		if value, ok := fields[field.FieldName]; ok && !isBlankFieldValue(value) {
+			// 弹窗会将模板原文作为初始值提交，其他值视为用户填写的内容
+			if text, isText := value.(string); !isText || strings.TrimSpace(text) != strings.TrimSpace(field.Default) {
+				continue
+			}
+		}
+
+		value, err := s.render(field.Default, ctx, reservation)
+		if err != nil {
+			s.ReleaseSequences(reservation)
+			return nil, fmt.Errorf("字段 '%s' 的默认值模板无效: %w", field.FieldName, err)
+		}
+		fields[field.FieldName] = value
+	}
+	return reservation, nil
+}
+
+// Preview 计算数据表所有模板默认值的当前结果，不递增序号
+func (s *DefaultValueService) Preview(writeFields []models.WriteField, ctx DefaultTemplateContext) (map[string]string, error) {
+	ctx.Preview = true
+	values := make(map[string]string)
+	for _, field := range writeFields {
+		if !IsDefaultTemplate(field.Default) {
+			continue
+		}
+		value, err := s.Render(field.Default, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("字段 '%s' 的默认值模板无效: %w", field.FieldName, err)
+		}
+		values[field.FieldName] = value
+	}
+	return values, nil
+}
+
+// Render 计算默认值模板，非预览时使用的序号直接保存
+func (s *DefaultValueService) Render(tmpl string, ctx DefaultTemplateContext) (string, error) {
+	reservation := &SequenceReservation{key: sequenceKey(ctx)}
+	value, err := s.render(tmpl, ctx, reservation)
+	if err != nil {
+		s.ReleaseSequences(reservation)
+		return "", err
+	}
+	s.CommitSequences(reservation)
+	return value, nil
+}
+
+// render 计算默认值模板，非预览时使用的序号记录到reservation
+func (s *DefaultValueService) render(tmpl string, ctx DefaultTemplateContext, reservation *SequenceReservation) (string, error) {
+	if ctx.Now.IsZero() {
+		ctx.Now = time.Now()
+	}
+	return renderDefaultTemplate(tmpl, ctx, func(preview bool) int64 {
+		return s.nextSequence(reservation, preview)
+	})
+}
+
+// sequenceKey 返回数据表序号的key
+func sequenceKey(ctx DefaultTemplateContext) string {
+	return ctx.AppToken + "/" + ctx.TableID
+}
+
+// nextSequence 返回数据表的下一个序号，preview为false时预留该序号
+// 已预留的序号不会再分配给其他提交，写入成功后才保存
+func (s *DefaultValueService) nextSequence(reservation *SequenceReservation, preview bool) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := s.sequences[reservation.key]
+	if reserved := s.reserved[reservation.key]; reserved > next {
+		next = reserved
+	}
+	next++
+	if !preview {
+		s.reserved[reservation.key] = next
+		reservation.numbers = append(reservation.numbers, next)
+	}
+	return next
+}
+
+// CommitSequences 记录写入成功后保存预留的序号
+func (s *DefaultValueService) CommitSequences(reservation *SequenceReservation) {
+	if reservation == nil || len(reservation.numbers) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, n := range reservation.numbers {
+		if n > s.sequences[reservation.key] {
+			s.sequences[reservation.key] = n
+		}
+	}
+	if s.reserved[reservation.key] <= s.sequences[reservation.key] {
+		delete(s.reserved, reservation.key)
+	}
+	reservation.numbers = nil
+	if err := writeJSONFile(s.path, s.sequences); err != nil {
+		logError("保存序号失败: %v", err)
+	}
+}
+
+// ReleaseSequences 归还未使用的预留序号
+// 只有最后预留的序号可以归还；并发提交时较早的提交失败会留下空号
+func (s *DefaultValueService) ReleaseSequences(reservation *SequenceReservation) {
+	if reservation == nil || len(reservation.numbers) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(reservation.numbers) - 1; i >= 0; i-- {
+		if s.reserved[reservation.key] == reservation.numbers[i] {
+			s.reserved[reservation.key]--
+		}
+	}
+	if s.reserved[reservation.key] <= s.sequences[reservation.key] {
+		delete(s.reserved, reservation.key)
+	}
+	reservation.numbers = nil
+}
+
+// renderDefaultTemplate 替换模板中的所有 {{...}} 表达式
+func renderDefaultTemplate(tmpl string, ctx DefaultTemplateContext, seq func(preview bool) int64) (string, error) {
+	var out strings.Builder
+	rest := tmpl
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			out.WriteString(rest)
+			return out.String(), nil
+		}
+		end := strings.Index(rest[start:], "}}")
+		if end < 0 {
+			return "", fmt.Errorf("缺少 }}")
+		}
+		out.WriteString(rest[:start])
+
+		value, err := evalTemplateExpr(rest[start+2:start+end], ctx, seq)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(value)
+		rest = rest[start+end+2:]
+	}
+}
+
+// evalTemplateExpr 计算单个表达式，格式为 值 [| 过滤器 参数]...
+func evalTemplateExpr(expr string, ctx DefaultTemplateContext, seq func(preview bool) int64) (string, error) {
+	stages, err := splitTemplateStages(expr)
+	if err != nil {
+		return "", err
+	}
+	args, err := templateArgs(stages[0])
+	if err != nil {
+		return "", err
+	}
+	if len(args) == 0 {
+		return "", fmt.Errorf("表达式为空")
+	}
+
+	// 时间值在过滤器处理后再转换为文本
+	var t time.Time
+	var text string
+	isTime := false
+
+	name, offset := splitTemplateOffset(args[0])
+	if offset != "" && name != "now" && name != "today" {
+		return "", fmt.Errorf("只有 now 和 today 支持时间偏移: %s", args[0])
+	}
+	switch name {
+	case "now", "today":
+		loc := ctx.Location
+		if loc == nil {
+			loc = time.Local
+		}
+		t = ctx.Now.In(loc)
+		layout := templateNowLayout
+		if name == "today" {
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+			layout = templateTodayLayout
+		}
+		if offset != "" {
+			if t, err = applyTemplateOffset(t, offset); err != nil {
+				return "", err
+			}
+		}
+		text = t.Format(layout)
+		isTime = true
+	case "seq":
+		format := "%d"
+		if len(args) > 1 {
+			format = args[1]
+		}
+		if !strings.Contains(format, "%") || strings.Contains(fmt.Sprintf(format, 1), "%!") {
+			return "", fmt.Errorf("序号格式无效: %s", format)
+		}
+		text = fmt.Sprintf(format, seq(ctx.Preview))
+	case "uuid":
+		text, err = newUUID()
+		if err != nil {
+			return "", err
+		}
+	case "page.url":
+		text = ctx.Page.URL
+	case "page.title":
+		text = ctx.Page.Title
+	default:
+		return "", fmt.Errorf("不支持的变量: %s", args[0])
+	}
+
+	for _, stage := range stages[1:] {
+		filter, err := templateArgs(stage)
+		if err != nil {
+			return "", err
+		}
+		if len(filter) == 0 {
+			return "", fmt.Errorf("过滤器为空")
+		}
+		switch filter[0] {
+		case "date":
+			if !isTime {
+				return "", fmt.Errorf("date 过滤器只能用于 now 和 today")
+			}
+			if len(filter) != 2 {
+				return "", fmt.Errorf("date 过滤器需要一个日期格式参数")
+			}
+			text = t.Format(filter[1])
+		case "upper":
+			text = strings.ToUpper(text)
+		case "lower":
+			text = strings.ToLower(text)
+		default:
+			return "", fmt.Errorf("不支持的过滤器: %s", filter[0])
+		}
+	}
+	return text, nil
+}
+
+// splitTemplateStages 按 | 拆分表达式中的值和过滤器，引号中的 | 不作为分隔符
+func splitTemplateStages(expr string) ([]string, error) {
+	var stages []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(expr); i++ {
+		switch ch := expr[i]; {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '`':
+			quote = ch
+		case ch == '|':
+			stages = append(stages, expr[start:i])
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("字符串缺少结束引号: %s", strings.TrimSpace(expr))
+	}
+	return append(stages, expr[start:]), nil
+}
+
+// templateArgs 将表达式拆分为名称和参数，参数可以是带引号的字符串
+func templateArgs(stage string) ([]string, error) {
+	var args []string
+	rest := strings.TrimSpace(stage)
+	for rest != "" {
+		if rest[0] == '"' || rest[0] == '`' {
+			end := strings.IndexByte(rest[1:], rest[0])
+			if end < 0 {
+				return nil, fmt.Errorf("字符串缺少结束引号: %s", rest)
+			}
+			quoted := rest[:end+2]
+			value, err := strconv.Unquote(quoted)
+			if err != nil {
+				return nil, fmt.Errorf("字符串格式无效: %s", quoted)
+			}
+			args = append(args, value)
+			rest = strings.TrimSpace(rest[end+2:])
+			continue
+		}
+
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			end = len(rest)
+		}
+		args = append(args, rest[:end])
+		rest = strings.TrimSpace(rest[end:])
+	}
+	return args, nil
+}
+
+// splitTemplateOffset 拆分 today+3d 形式的时间偏移
+func splitTemplateOffset(arg string) (string, string) {
+	if i := strings.IndexAny(arg, "+-"); i > 0 {
+		return arg[:i], arg[i:]
+	}
+	return arg, ""
+}
+
+// applyTemplateOffset 应用时间偏移，支持 d（天）、w（周）、h（小时）
+func applyTemplateOffset(t time.Time, offset string) (time.Time, error) {
+	unit := offset[len(offset)-1]
+	n, err := strconv.Atoi(offset[:len(offset)-1])
+	if err != nil {
+		return t, fmt.Errorf("时间偏移无效: %s", offset)
+	}
+	switch unit {
+	case 'd':
+		return t.AddDate(0, 0, n), nil
+	case 'w':
+		return t.AddDate(0, 0, 7*n), nil
+	case 'h':
+		return t.Add(time.Duration(n) * time.Hour), nil
+	}
+	return t, fmt.Errorf("时间偏移单位无效: %s，支持 d、w、h", offset)
+}
+
+// newUUID 生成随机的UUID（版本4）
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成UUID失败: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,462 @@
+package services
+
+import (
+	"fmt"
+	"lark-record/models"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // 保证在没有时区数据库的系统上也能加载配置的时区
+)
+
+// 字段值编码错误码
+const (
+	FieldErrInvalidNumber   = "invalid_number"   // 不是有效的数字
+	FieldErrInvalidDate     = "invalid_date"     // 不是有效的日期
+	FieldErrInvalidCheckbox = "invalid_checkbox" // 不是有效的复选框值
+	FieldErrInvalidValue    = "invalid_value"    // 值的格式与字段类型不匹配
+	FieldErrOutOfRange      = "out_of_range"     // 数值超出字段允许的范围
+	FieldErrReadOnly        = "read_only"        // 字段由飞书自动生成，不能写入
+	FieldErrUnknownUser     = "unknown_user"     // 无法根据邮箱找到用户
+	FieldErrUnknownLink     = "unknown_link"     // 关联表中找不到对应的记录
+	FieldErrAmbiguousLink   = "ambiguous_link"   // 关联表中有多条记录对应同一个值
+)
+
+// 日期字段支持的文本格式
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006/01/02",
+	"2006年01月02日 15:04",
+	"2006年01月02日",
+	"2006年1月2日",
+}
+
+// compactDatePattern 不带分隔符的日期，如 20240105
+var compactDatePattern = regexp.MustCompile(`^\d{8}$`)
+
+// LoadFieldLocation 加载解析日期字段使用的时区（IANA名称，如 Asia/Shanghai），为空时使用服务器本地时区
+func LoadFieldLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("无效的时区: %s", name)
+	}
+	return loc, nil
+}
+
+// RecordOptions 写入记录时按配置处理字段值的选项
+type RecordOptions struct {
+	Location    *time.Location      // 解析和显示日期使用的时区，为nil时使用服务器本地时区
+	WriteFields []models.WriteField // 目标数据表写入字段的配置（选项策略、关联记录自动新建等）
+	Strict      bool                // 目标数据表开启了严格校验，导入时按严格校验检查每一行
+	DryRun      bool                // 只校验不写入，不新建选项和关联记录
+}
+
+// writeField 返回字段的写入配置，未配置时返回零值
+func (o RecordOptions) writeField(name string) models.WriteField {
+	for _, field := range o.WriteFields {
+		if field.FieldName == name {
+			return field
+		}
+	}
+	return models.WriteField{}
+}
+
+// location 返回解析日期使用的时区
+func (o RecordOptions) location() *time.Location {
+	if o.Location != nil {
+		return o.Location
+	}
+	return time.Local
+}
+
+// FieldValueError 单个字段值的编码错误
+type FieldValueError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *FieldValueError) Error() string {
+	return fmt.Sprintf("字段 '%s': %s", e.Field, e.Message)
+}
+
+// FieldValueErrors 一条记录中所有字段的编码错误
+type FieldValueErrors []*FieldValueError
+
+func (errs FieldValueErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// fieldKind 返回字段的类型名称，优先使用ui_type，旧数据没有ui_type时根据field_type判断
+func fieldKind(field models.Field) string {
+	if field.UiType != "" {
+		return field.UiType
+	}
+	switch field.FieldType {
+	case "1":
+		return "Text"
+	case "2":
+		return "Number"
+	case "3":
+		return "SingleSelect"
+	case "4":
+		return "MultiSelect"
+	case "5":
+		return "DateTime"
+	case "7":
+		return "Checkbox"
+	case "11":
+		return "User"
+	case "13":
+		return "Phone"
+	case "15":
+		return "Url"
+	case "17":
+		return "Attachment"
+	case "18":
+		return "SingleLink"
+	case "19":
+		return "Lookup"
+	case "20":
+		return "Formula"
+	case "21":
+		return "DuplexLink"
+	case "22":
+		return "Location"
+	case "23":
+		return "GroupChat"
+	case "1001":
+		return "CreatedTime"
+	case "1002":
+		return "ModifiedTime"
+	case "1003":
+		return "CreatedUser"
+	case "1004":
+		return "ModifiedUser"
+	case "1005":
+		return "AutoNumber"
+	}
+	return field.FieldType
+}
+
+// EncodeRecordFields 按字段类型将一条记录的所有字段值转换为飞书接口需要的格式，日期文本按loc解析
+// 空值会被忽略，表格中不存在的字段原样保留，由飞书接口返回错误
+func EncodeRecordFields(tableFields []models.Field, fields map[string]interface{}, loc *time.Location) (map[string]interface{}, error) {
+	fieldsByName := make(map[string]models.Field, len(tableFields))
+	for _, field := range tableFields {
+		fieldsByName[field.FieldName] = field
+	}
+
+	encoded := make(map[string]interface{}, len(fields))
+	var errs FieldValueErrors
+	for name, value := range fields {
+		if isBlankFieldValue(value) {
+			continue
+		}
+		field, ok := fieldsByName[name]
+		if !ok {
+			encoded[name] = value
+			continue
+		}
+
+		v, err := EncodeFieldValue(field, value, loc)
+		if err != nil {
+			errs = append(errs, err.(*FieldValueError))
+			continue
+		}
+		encoded[name] = v
+	}
+
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+		return nil, errs
+	}
+	return encoded, nil
+}
+
+// EncodeFieldValue 将用户输入转换为字段类型对应的飞书接口格式，日期文本按loc解析，loc为nil时使用服务器本地时区
+// 支持字符串输入（弹窗和导入文件）以及已经是接口格式的值，重复编码结果不变，不会修改传入的值
+func EncodeFieldValue(field models.Field, value interface{}, loc *time.Location) (interface{}, error) {
+	kind := fieldKind(field)
+	fail := func(code, format string, args ...interface{}) (interface{}, error) {
+		return nil, &FieldValueError{Field: field.FieldName, Code: code, Message: fmt.Sprintf(format, args...)}
+	}
+
+	switch kind {
+	case "Text", "Email", "Barcode", "Phone":
+		switch v := value.(type) {
+		case string:
+			return strings.TrimSpace(v), nil
+		case float64, int, int64:
+			return fmt.Sprintf("%v", v), nil
+		}
+		// 文本字段的富文本片段原样写入
+		return value, nil
+
+	case "Number", "Currency", "Rating", "Progress":
+		n, ok := toNumber(value, kind)
+		if !ok {
+			return fail(FieldErrInvalidNumber, "'%v' 不是有效的数字", value)
+		}
+		switch kind {
+		case "Rating":
+			if n != math.Trunc(n) || n < 0 {
+				return fail(FieldErrOutOfRange, "评分必须是非负整数: %v", value)
+			}
+		case "Progress":
+			if n < 0 || n > 1 {
+				return fail(FieldErrOutOfRange, "进度必须是 0 到 1 之间的小数或 0%% 到 100%% 的百分比（如 0.5 或 50%%）: %v", value)
+			}
+		}
+		return n, nil
+
+	case "SingleSelect":
+		switch v := value.(type) {
+		case string:
+			return strings.TrimSpace(v), nil
+		case []interface{}:
+			if len(v) == 1 {
+				if s, ok := v[0].(string); ok {
+					return strings.TrimSpace(s), nil
+				}
+			}
+		case []string:
+			if len(v) == 1 {
+				return strings.TrimSpace(v[0]), nil
+			}
+		}
+		return fail(FieldErrInvalidValue, "单选字段只能选择一个选项")
+
+	case "MultiSelect":
+		options, ok := toStringList(value)
+		if !ok {
+			return fail(FieldErrInvalidValue, "多选字段的值必须是选项列表")
+		}
+		return options, nil
+
+	case "DateTime":
+		ms, ok := toTimestamp(value, loc)
+		if !ok {
+			return fail(FieldErrInvalidDate, "'%v' 不是有效的日期，支持日期文本、毫秒时间戳或以@开头的秒级时间戳", value)
+		}
+		return ms, nil
+
+	case "Checkbox":
+		b, ok := toBool(value)
+		if !ok {
+			return fail(FieldErrInvalidCheckbox, "'%v' 不是有效的复选框值", value)
+		}
+		return b, nil
+
+	case "User":
+		if list, ok := value.([]interface{}); ok && len(list) > 0 {
+			if _, isMap := list[0].(map[string]interface{}); isMap {
+				return value, nil
+			}
+		}
+		ids, ok := toStringList(value)
+		if !ok {
+			return fail(FieldErrInvalidValue, "人员字段的值必须是用户ID或邮箱")
+		}
+		users := make([]interface{}, 0, len(ids))
+		for _, id := range ids {
+			users = append(users, map[string]interface{}{"id": id})
+		}
+		return users, nil
+
+	case "Url":
+		switch v := value.(type) {
+		case string:
+			link := strings.TrimSpace(v)
+			return map[string]interface{}{"link": link, "text": link}, nil
+		case map[string]interface{}:
+			if link, _ := v["link"].(string); link != "" {
+				// 复制后再补充显示文本，避免修改调用方的字段
+				encoded := make(map[string]interface{}, len(v)+1)
+				for key, item := range v {
+					encoded[key] = item
+				}
+				if text, _ := encoded["text"].(string); text == "" {
+					encoded["text"] = link
+				}
+				return encoded, nil
+			}
+		}
+		return fail(FieldErrInvalidValue, "超链接字段的值必须是URL")
+
+	case "SingleLink", "DuplexLink":
+		// 关联记录以记录ID或关联表主字段的值填写，由写入流程查找对应的记录ID
+		if m, ok := value.(map[string]interface{}); ok {
+			value = m["link_record_ids"]
+		}
+		items, ok := toStringList(value)
+		if !ok {
+			return fail(FieldErrInvalidValue, "关联字段的值必须是记录ID或关联记录的标题")
+		}
+		return items, nil
+
+	case "Formula", "Lookup", "CreatedTime", "ModifiedTime", "CreatedUser", "ModifiedUser", "AutoNumber":
+		return fail(FieldErrReadOnly, "该字段由飞书自动生成，不能写入")
+	}
+
+	// 附件、地理位置等字段由对应的处理流程生成接口格式
+	return value, nil
+}
+
+// isBlankFieldValue 判断是否为未填写的值
+func isBlankFieldValue(value interface{}) bool {
+	if s, ok := value.(string); ok {
+		return strings.TrimSpace(s) == ""
+	}
+	return isEmptyFieldValue(value)
+}
+
+// toNumber 将输入转换为数字，支持千分位、货币符号和百分比
+// 百分比按小数写入（50% 为 0.5），与飞书数字字段的百分比格式和进度字段一致，货币和评分字段不接受百分比
+func toNumber(value interface{}, kind string) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		text := strings.TrimSpace(v)
+		text = strings.NewReplacer(",", "", "，", "", "¥", "", "￥", "", "$", "", "€", "", " ", "").Replace(text)
+		percent := strings.HasSuffix(text, "%")
+		text = strings.TrimSuffix(text, "%")
+		n, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return 0, false
+		}
+		if percent {
+			if kind != "Number" && kind != "Progress" {
+				return 0, false
+			}
+			n /= 100
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+// toTimestamp 将输入转换为毫秒时间戳，日期文本按loc解析
+// 数字与飞书日期字段一致视为毫秒时间戳；文本中8位数字按 20240105 形式的日期解析，
+// 以@开头的数字（如 @1704412800）视为秒级时间戳，10位及以上的数字视为毫秒时间戳
+func toTimestamp(value interface{}, loc *time.Location) (int64, bool) {
+	if loc == nil {
+		loc = time.Local
+	}
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case float64:
+		return int64(v), true
+	case string:
+		text := strings.TrimSpace(v)
+		if strings.HasPrefix(text, "@") {
+			seconds, err := strconv.ParseInt(text[1:], 10, 64)
+			if err != nil {
+				return 0, false
+			}
+			return seconds * 1000, true
+		}
+		if compactDatePattern.MatchString(text) {
+			if t, err := time.ParseInLocation("20060102", text, loc); err == nil {
+				return t.UnixMilli(), true
+			}
+			return 0, false
+		}
+		if len(text) >= 10 {
+			if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+				return n, true
+			}
+		}
+		for _, layout := range dateLayouts {
+			if t, err := time.ParseInLocation(layout, text, loc); err == nil {
+				return t.UnixMilli(), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// toBool 将输入转换为复选框的布尔值
+func toBool(value interface{}) (bool, bool) {
+	switch v := value.(type) {
+	case bool:
+		return v, true
+	case float64:
+		return v != 0, v == 0 || v == 1
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "yes", "y", "1", "on", "是", "✓", "√":
+			return true, true
+		case "false", "no", "n", "0", "off", "否":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// toStringList 将逗号分隔的文本或数组转换为字符串列表
+func toStringList(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case string:
+		return splitList(v), true
+	case []string:
+		var items []string
+		for _, item := range v {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, true
+	case []interface{}:
+		var items []string
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			if s = strings.TrimSpace(s); s != "" {
+				items = append(items, s)
+			}
+		}
+		return items, true
+	}
+	return nil, false
+}
+
+// splitList 拆分多值文本，支持中英文逗号、分号、顿号和竖线分隔
+func splitList(text string) []string {
+	parts := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == '，' || r == ';' || r == '；' || r == '、' || r == '|' || r == '\n'
+	})
+	var items []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			items = append(items, part)
+		}
+	}
+	return items
+}
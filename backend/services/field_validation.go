@@ -0,0 +1,95 @@
+package services
+
+import (
+	"fmt"
+	"lark-record/models"
+	"sort"
+)
+
+// 严格校验错误码
+const (
+	FieldErrUnknownField  = "unknown_field"  // 表格中不存在该字段
+	FieldErrInvalidOption = "invalid_option" // 选项不在单选/多选字段的选项列表中
+	FieldErrRequired      = "required"       // 必填字段未填写
+)
+
+// ValidateRecordFields 写入前严格校验一条记录：字段是否存在、值是否符合字段类型、
+// 选项是否有效以及必填字段是否填写，返回的错误按字段名排序
+// 配置了选项策略create/other的字段接受新选项，写入时按策略处理
+func ValidateRecordFields(tableFields []models.Field, fields map[string]interface{}, required []string, opts RecordOptions) FieldValueErrors {
+	fieldsByName := make(map[string]models.Field, len(tableFields))
+	for _, field := range tableFields {
+		fieldsByName[field.FieldName] = field
+	}
+
+	var errs FieldValueErrors
+	for name, value := range fields {
+		field, ok := fieldsByName[name]
+		if !ok {
+			errs = append(errs, &FieldValueError{Field: name, Code: FieldErrUnknownField, Message: "表格中不存在该字段"})
+			continue
+		}
+		if isBlankFieldValue(value) {
+			continue
+		}
+
+		encoded, err := EncodeFieldValue(field, value, opts.location())
+		if err != nil {
+			errs = append(errs, err.(*FieldValueError))
+			continue
+		}
+		switch opts.writeField(name).OptionPolicy {
+		case models.OptionPolicyCreate, models.OptionPolicyOther:
+		default:
+			if err := checkFieldOptions(field, encoded); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	for _, name := range required {
+		if value, ok := fields[name]; !ok || isBlankFieldValue(value) {
+			errs = append(errs, &FieldValueError{Field: name, Code: FieldErrRequired, Message: "必填字段未填写"})
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
+
+// checkFieldOptions 检查单选/多选字段的值是否在选项列表中，没有选项信息时不检查
+func checkFieldOptions(field models.Field, encoded interface{}) *FieldValueError {
+	if len(field.Options) == 0 {
+		return nil
+	}
+
+	var values []string
+	switch v := encoded.(type) {
+	case string:
+		values = []string{v}
+	case []string:
+		values = v
+	default:
+		return nil
+	}
+
+	options := make(map[string]bool, len(field.Options))
+	for _, option := range field.Options {
+		options[option] = true
+	}
+	for _, value := range values {
+		if !options[value] {
+			return &FieldValueError{Field: field.FieldName, Code: FieldErrInvalidOption, Message: fmt.Sprintf("'%s' 不是有效的选项", value)}
+		}
+	}
+	return nil
+}
+
+// ValidateRecord 获取数据表字段后严格校验一条记录
+func (s *LarkService) ValidateRecord(appToken, tableID string, fields map[string]interface{}, required []string, opts RecordOptions) (FieldValueErrors, error) {
+	tableFields, err := s.GetTableFields(appToken, tableID)
+	if err != nil {
+		return nil, fmt.Errorf("获取表格字段失败: %w", err)
+	}
+	return ValidateRecordFields(tableFields, fields, required, opts), nil
+}
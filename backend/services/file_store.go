@@ -0,0 +1,64 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// readJSONFile 从JSON文件读取数据，文件不存在时返回false
+func readJSONFile(path string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("读取文件 %s 失败: %v", path, err)
+	}
+	if len(data) == 0 {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("解析文件 %s 失败: %v", path, err)
+	}
+	return true, nil
+}
+
+// writeJSONFile 将数据写入JSON文件
+// 先写入临时文件再重命名，避免进程中断时留下损坏的文件
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("转换为JSON失败: %v", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("写入文件 %s 失败: %v", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("写入文件 %s 失败: %v", path, err)
+	}
+	return nil
+}
+
+// appendJSONLine 将数据转换为一行JSON追加到文件末尾，文件不存在时创建
+func appendJSONLine(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("转换为JSON失败: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("写入文件 %s 失败: %v", path, err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("写入文件 %s 失败: %v", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("写入文件 %s 失败: %v", path, err)
+	}
+	return nil
+}
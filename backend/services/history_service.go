@@ -0,0 +1,341 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"lark-record/models"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 提交记录的保留配置
+const (
+	DefaultHistoryRetention = 90 * 24 * time.Hour // 默认保留90天
+	maxHistoryEntries       = 20000               // 最多保留的提交记录数量，超出时删除最早的记录
+	DefaultHistoryLimit     = 100                 // 查询默认返回的数量
+	MaxHistoryLimit         = 1000                // 查询最多返回的数量
+	historyCompactEvery     = 500                 // 日志追加多少次后合并到记录文件
+)
+
+// larkCodePattern 从错误信息中提取飞书接口返回码
+var larkCodePattern = regexp.MustCompile(`\(Code: (-?\d+)\)`)
+
+// LarkErrorCode 从写入错误中提取飞书接口返回码，成功返回0，没有收到飞书响应返回-1
+func LarkErrorCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if m := larkCodePattern.FindStringSubmatch(err.Error()); m != nil {
+		if code, convErr := strconv.Atoi(m[1]); convErr == nil {
+			return code
+		}
+	}
+	return -1
+}
+
+// HistoryService 提交记录审计日志
+// 每次提交的内容和结果保存在本地文件中，字段检测、完成通知和任务的结果在之后更新到同一条日志
+// 新增和更新的记录先追加到日志文件（path.journal），追加一定次数后合并到记录文件并清理过期记录
+type HistoryService struct {
+	mu            sync.Mutex
+	path          string
+	journalPath   string
+	appended      int                  // 上次合并后追加到日志文件的次数
+	entries       []*models.Submission // 按提交时间从早到晚排序
+	configService *ConfigService
+}
+
+// NewHistoryService 创建提交记录服务并加载已保存的记录
+func NewHistoryService(path string, configService *ConfigService) *HistoryService {
+	if path == "" {
+		path = "./history.json"
+	}
+	s := &HistoryService{
+		path:          path,
+		journalPath:   path + ".journal",
+		configService: configService,
+	}
+	if _, err := readJSONFile(path, &s.entries); err != nil {
+		logError("加载提交记录失败: %v", err)
+	}
+	replayed, err := s.replayJournal()
+	if err != nil {
+		logError("加载提交记录日志失败: %v", err)
+	}
+	if replayed > 0 {
+		s.compactLocked()
+	}
+	return s
+}
+
+// Record 保存一条提交记录，附件的文件内容不会保存
+func (s *HistoryService) Record(entry models.Submission) models.Submission {
+	return s.RecordAll([]models.Submission{entry})[0]
+}
+
+// RecordAll 保存多条提交记录
+func (s *HistoryService) RecordAll(entries []models.Submission) []models.Submission {
+	now := time.Now()
+	saved := make([]models.Submission, len(entries))
+	for i, entry := range entries {
+		id, err := newRandomID()
+		if err != nil {
+			id = strconv.FormatInt(now.UnixNano()+int64(i), 36)
+		}
+		entry.ID = id
+		if entry.Time.IsZero() {
+			entry.Time = now
+		}
+		entry.UpdatedAt = entry.Time
+		entry.Fields = historyFields(entry.Fields)
+		saved[i] = entry
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range saved {
+		entry := saved[i]
+		s.entries = append(s.entries, &entry)
+		s.appendLocked(&entry)
+	}
+	return saved
+}
+
+// Get 获取指定ID的提交记录
+func (s *HistoryService) Get(id string) (models.Submission, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		if s.entries[i].ID == id {
+			return *s.entries[i], true
+		}
+	}
+	return models.Submission{}, false
+}
+
+// Update 更新指定ID的提交记录
+func (s *HistoryService) Update(id string, update func(*models.Submission)) {
+	s.updateLatest(func(entry *models.Submission) bool { return entry.ID == id }, update)
+}
+
+// UpdateByRecordID 更新记录ID对应的最近一条提交记录，没有对应的提交记录时不做处理
+func (s *HistoryService) UpdateByRecordID(recordID string, update func(*models.Submission)) {
+	if recordID == "" {
+		return
+	}
+	s.updateLatest(func(entry *models.Submission) bool { return entry.RecordID == recordID }, update)
+}
+
+// UpdateByOutboxID 更新待提交队列项对应的提交记录
+func (s *HistoryService) UpdateByOutboxID(outboxID string, update func(*models.Submission)) {
+	if outboxID == "" {
+		return
+	}
+	s.updateLatest(func(entry *models.Submission) bool { return entry.OutboxID == outboxID }, update)
+}
+
+// updateLatest 从最近的记录开始查找第一条匹配的记录并更新
+func (s *HistoryService) updateLatest(match func(*models.Submission) bool, update func(*models.Submission)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		if match(s.entries[i]) {
+			update(s.entries[i])
+			s.entries[i].UpdatedAt = time.Now()
+			s.appendLocked(s.entries[i])
+			return
+		}
+	}
+}
+
+// Query 按条件查询提交记录，按提交时间从新到旧排序，同时返回符合条件的总数
+func (s *HistoryService) Query(q models.HistoryQuery) ([]models.Submission, int) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	if limit > MaxHistoryLimit {
+		limit = MaxHistoryLimit
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []models.Submission{}
+	total := 0
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		entry := s.entries[i]
+		if (q.AppToken != "" && entry.AppToken != q.AppToken) ||
+			(q.TableID != "" && entry.TableID != q.TableID) ||
+			(q.Status != "" && entry.Status != q.Status) ||
+			(q.RecordID != "" && entry.RecordID != q.RecordID) ||
+			(q.Client != "" && entry.Client != q.Client) ||
+			(!q.From.IsZero() && entry.Time.Before(q.From)) ||
+			(!q.To.IsZero() && !entry.Time.Before(q.To)) {
+			continue
+		}
+		total++
+		if len(result) < limit {
+			result = append(result, *entry)
+		}
+	}
+	return result, total
+}
+
+// ParseHistoryTime 解析查询条件中的时间，支持RFC3339和按loc解释的日期（2006-01-02）
+// endOfDay为true时日期表示当天结束，用于查询范围的结束时间
+func ParseHistoryTime(value string, endOfDay bool, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("时间格式无效: %s，支持 2006-01-02 或 RFC3339", value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// pruneLocked 删除超过保留时间或超出数量上限的记录，调用方需持有锁
+func (s *HistoryService) pruneLocked() {
+	retention := DefaultHistoryRetention
+	if s.configService != nil {
+		if days := s.configService.GetConfig().HistoryRetentionDays; days > 0 {
+			retention = time.Duration(days) * 24 * time.Hour
+		}
+	}
+	cutoff := time.Now().Add(-retention)
+
+	start := sort.Search(len(s.entries), func(i int) bool {
+		return !s.entries[i].Time.Before(cutoff)
+	})
+	if overflow := len(s.entries) - maxHistoryEntries; overflow > start {
+		start = overflow
+	}
+	if start > 0 {
+		s.entries = append([]*models.Submission(nil), s.entries[start:]...)
+	}
+}
+
+// appendLocked 将新增或更新后的提交记录追加到日志文件，追加次数达到上限时合并到记录文件，调用方需持有锁
+func (s *HistoryService) appendLocked(entry *models.Submission) {
+	if err := appendJSONLine(s.journalPath, entry); err != nil {
+		// 追加失败时直接合并，避免丢失这次修改
+		logError("追加提交记录日志失败: %v", err)
+		s.compactLocked()
+		return
+	}
+	s.appended++
+	if s.appended >= historyCompactEvery {
+		s.compactLocked()
+	}
+}
+
+// compactLocked 清理过期记录后将全部提交记录写入记录文件并清空日志文件，调用方需持有锁
+func (s *HistoryService) compactLocked() {
+	s.pruneLocked()
+	if err := writeJSONFile(s.path, s.entries); err != nil {
+		logError("保存提交记录失败: %v", err)
+		return
+	}
+	if err := os.Truncate(s.journalPath, 0); err != nil && !os.IsNotExist(err) {
+		logError("清空提交记录日志失败: %v", err)
+		return
+	}
+	s.appended = 0
+}
+
+// replayJournal 将日志文件中的记录应用到已加载的提交记录，ID相同的记录以日志中最后一次为准，返回应用的行数
+// 进程中断时最后一行可能不完整，解析失败的行会被跳过
+func (s *HistoryService) replayJournal() (int, error) {
+	file, err := os.Open(s.journalPath)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("读取文件 %s 失败: %v", s.journalPath, err)
+	}
+	defer file.Close()
+
+	index := make(map[string]int, len(s.entries))
+	for i, entry := range s.entries {
+		index[entry.ID] = i
+	}
+
+	replayed := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry models.Submission
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.ID == "" {
+			continue
+		}
+		if i, ok := index[entry.ID]; ok {
+			s.entries[i] = &entry
+		} else {
+			index[entry.ID] = len(s.entries)
+			s.entries = append(s.entries, &entry)
+		}
+		replayed++
+	}
+	if err := scanner.Err(); err != nil {
+		return replayed, fmt.Errorf("读取文件 %s 失败: %v", s.journalPath, err)
+	}
+	return replayed, nil
+}
+
+// historyFields 复制字段值，去掉以base64提交的附件内容，只保留文件名
+func historyFields(fields map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		copied[name] = stripAttachmentContent(value)
+	}
+	return copied
+}
+
+// stripAttachmentContent 去掉附件对象中的content
+func stripAttachmentContent(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = stripAttachmentContent(item)
+		}
+		return items
+	case map[string]interface{}:
+		if _, ok := v["content"]; !ok {
+			return v
+		}
+		stripped := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if key != "content" {
+				stripped[key] = item
+			}
+		}
+		return stripped
+	}
+	return value
+}
+
+// newActionResult 根据执行错误生成通知或任务的执行结果，id为消息ID或任务GUID
+func newActionResult(id string, err error) *models.ActionResult {
+	result := &models.ActionResult{Status: models.ActionSucceeded, ID: id, Time: time.Now()}
+	if err != nil {
+		result.Status = models.ActionFailed
+		result.Error = err.Error()
+	}
+	return result
+}
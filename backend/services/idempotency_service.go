@@ -0,0 +1,154 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"lark-record/models"
+	"sync"
+	"time"
+)
+
+// DefaultIdempotencyWindow 未配置时幂等键的保留时间
+const DefaultIdempotencyWindow = 24 * time.Hour
+
+var (
+	// ErrIdempotencyInProgress 相同幂等键的请求尚未处理完成
+	ErrIdempotencyInProgress = errors.New("相同幂等键的请求正在处理中，请稍后重试")
+	// ErrIdempotencyKeyReused 幂等键已用于内容不同的请求
+	ErrIdempotencyKeyReused = errors.New("幂等键已用于内容不同的请求")
+)
+
+// IdempotencyService 记录幂等键对应的请求结果，避免客户端超时重试时重复创建记录
+// 只保存成功的响应，结果持久化到本地文件，服务重启后仍然有效
+type IdempotencyService struct {
+	mu            sync.Mutex
+	path          string
+	entries       map[string]*models.IdempotencyEntry
+	pending       map[string]string // 正在处理的幂等键 -> 请求体摘要
+	configService *ConfigService
+}
+
+// NewIdempotencyService 创建幂等键服务并加载未过期的记录
+func NewIdempotencyService(path string, configService *ConfigService) *IdempotencyService {
+	if path == "" {
+		path = "./idempotency.json"
+	}
+	s := &IdempotencyService{
+		path:          path,
+		entries:       make(map[string]*models.IdempotencyEntry),
+		pending:       make(map[string]string),
+		configService: configService,
+	}
+
+	var entries []*models.IdempotencyEntry
+	if _, err := readJSONFile(path, &entries); err != nil {
+		logError("加载幂等键记录失败: %v", err)
+	}
+	for _, entry := range entries {
+		s.entries[entry.Key] = entry
+	}
+	s.pruneLocked(time.Now())
+	return s
+}
+
+// window 返回配置的幂等键保留时间
+func (s *IdempotencyService) window() time.Duration {
+	if s.configService != nil {
+		if hours := s.configService.GetConfig().IdempotencyWindowHours; hours > 0 {
+			return time.Duration(hours) * time.Hour
+		}
+	}
+	return DefaultIdempotencyWindow
+}
+
+// Begin 开始处理带幂等键的请求
+// 已有结果时返回该结果供直接响应；返回nil表示调用方需要处理请求，之后调用Complete或Release
+func (s *IdempotencyService) Begin(key, fingerprint string) (*models.IdempotencyEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok && time.Since(entry.CreatedAt) < s.window() {
+		if entry.Fingerprint != fingerprint {
+			return nil, ErrIdempotencyKeyReused
+		}
+		return entry, nil
+	}
+
+	if pendingFingerprint, ok := s.pending[key]; ok {
+		if pendingFingerprint != fingerprint {
+			return nil, ErrIdempotencyKeyReused
+		}
+		return nil, ErrIdempotencyInProgress
+	}
+
+	s.pending[key] = fingerprint
+	return nil, nil
+}
+
+// Complete 保存请求的响应，之后相同幂等键的请求直接返回该响应
+func (s *IdempotencyService) Complete(key string, status int, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fingerprint, ok := s.pending[key]
+	if !ok {
+		return
+	}
+	delete(s.pending, key)
+
+	if !json.Valid(body) {
+		logError("⚠️ 幂等键 %s 的响应不是JSON，不保存结果", key)
+		return
+	}
+
+	now := time.Now()
+	s.entries[key] = &models.IdempotencyEntry{
+		Key:         key,
+		Fingerprint: fingerprint,
+		Status:      status,
+		Body:        append(json.RawMessage(nil), body...),
+		CreatedAt:   now,
+	}
+	s.pruneLocked(now)
+	s.saveLocked()
+}
+
+// Release 放弃处理中的幂等键（请求失败），客户端可以使用相同的幂等键重试
+func (s *IdempotencyService) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, key)
+}
+
+// Forget 删除幂等键保存的结果，之后使用该幂等键的请求会重新处理
+func (s *IdempotencyService) Forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[key]; !ok {
+		return
+	}
+	delete(s.entries, key)
+	s.saveLocked()
+}
+
+// pruneLocked 删除已过期的记录，调用方需持有锁
+func (s *IdempotencyService) pruneLocked(now time.Time) {
+	window := s.window()
+	for key, entry := range s.entries {
+		if now.Sub(entry.CreatedAt) >= window {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// saveLocked 保存所有记录到文件，调用方需持有锁
+func (s *IdempotencyService) saveLocked() {
+	entries := make([]*models.IdempotencyEntry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+	if err := writeJSONFile(s.path, entries); err != nil {
+		logError("保存幂等键记录失败: %v", err)
+	}
+}
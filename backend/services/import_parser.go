@@ -0,0 +1,134 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// 支持导入的文件格式
+const (
+	ImportFormatCSV   = "csv"
+	ImportFormatTSV   = "tsv"
+	ImportFormatJSONL = "jsonl"
+)
+
+// ImportRow 导入文件中的一行数据
+type ImportRow struct {
+	Line   int                    // 数据在文件中的行号
+	Values map[string]interface{} // 列名到单元格值的映射
+}
+
+// ImportFormatFromFilename 根据文件扩展名判断导入格式
+func ImportFormatFromFilename(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return ImportFormatCSV
+	case ".tsv", ".tab":
+		return ImportFormatTSV
+	case ".jsonl", ".ndjson":
+		return ImportFormatJSONL
+	}
+	return ""
+}
+
+// ParseImportFile 解析导入文件，CSV/TSV的第一行为列名，JSONL每行一个JSON对象
+func ParseImportFile(r io.Reader, format string) ([]ImportRow, error) {
+	switch format {
+	case ImportFormatCSV:
+		return parseDelimited(r, ',')
+	case ImportFormatTSV:
+		return parseDelimited(r, '\t')
+	case ImportFormatJSONL:
+		return parseJSONLines(r)
+	}
+	return nil, fmt.Errorf("不支持的导入格式: %s", format)
+}
+
+// parseDelimited 解析CSV/TSV文件
+func parseDelimited(r io.Reader, comma rune) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	if comma == '\t' {
+		reader.LazyQuotes = true
+	}
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("文件为空")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取列名失败: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	// 去掉Excel导出的UTF-8 BOM
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	var rows []ImportRow
+	for {
+		cells, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析文件失败: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		values := make(map[string]interface{})
+		blank := true
+		for i, cell := range cells {
+			if i >= len(header) || header[i] == "" {
+				continue
+			}
+			values[header[i]] = cell
+			if strings.TrimSpace(cell) != "" {
+				blank = false
+			}
+		}
+		// 跳过空行
+		if blank {
+			continue
+		}
+		rows = append(rows, ImportRow{Line: line, Values: values})
+	}
+	return rows, nil
+}
+
+// parseJSONLines 解析JSONL文件
+func parseJSONLines(r io.Reader) ([]ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+
+	var rows []ImportRow
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if text == "" {
+			continue
+		}
+
+		var values map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &values); err != nil {
+			return nil, fmt.Errorf("第 %d 行不是有效的JSON对象: %w", line, err)
+		}
+		rows = append(rows, ImportRow{Line: line, Values: values})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MaxAttachmentSize 单个附件的最大大小，与飞书 medias/upload_all 接口的上限一致
+const MaxAttachmentSize = 20 << 20
+
+// ErrInvalidAttachment 附件内容不符合要求（大小、类型或格式）
+var ErrInvalidAttachment = errors.New("附件无效")
+
+// 允许上传的附件类型，按 http.DetectContentType 识别的结果判断
+var allowedAttachmentTypes = []string{
+	"image/",
+	"text/",
+	"audio/",
+	"video/",
+	"application/pdf",
+	"application/zip", // docx/xlsx/pptx 等Office文档
+	"application/x-gzip",
+	"application/x-rar-compressed",
+	"application/json",
+}
+
+// 识别为 application/octet-stream 时，按扩展名允许的旧版Office文档
+var allowedBinaryExtensions = map[string]bool{
+	".doc": true,
+	".xls": true,
+	".ppt": true,
+}
+
+// AttachmentUpload 待上传的附件
+type AttachmentUpload struct {
+	FileName string
+	Data     []byte
+}
+
+// ValidateAttachment 检查附件的大小和类型
+func ValidateAttachment(upload AttachmentUpload) error {
+	if upload.FileName == "" {
+		return fmt.Errorf("%w: 缺少文件名", ErrInvalidAttachment)
+	}
+	if len(upload.Data) == 0 {
+		return fmt.Errorf("%w: 文件 '%s' 为空", ErrInvalidAttachment, upload.FileName)
+	}
+	if len(upload.Data) > MaxAttachmentSize {
+		return fmt.Errorf("%w: 文件 '%s' 超过 %d MB", ErrInvalidAttachment, upload.FileName, MaxAttachmentSize>>20)
+	}
+
+	contentType := http.DetectContentType(upload.Data)
+	for _, allowed := range allowedAttachmentTypes {
+		if strings.HasPrefix(contentType, allowed) {
+			return nil
+		}
+	}
+	if strings.HasPrefix(contentType, "application/octet-stream") && allowedBinaryExtensions[strings.ToLower(filepath.Ext(upload.FileName))] {
+		return nil
+	}
+	return fmt.Errorf("%w: 不支持的文件类型 %s (%s)", ErrInvalidAttachment, contentType, upload.FileName)
+}
+
+// UploadAttachment 上传附件到多维表格，返回用于写入附件字段的file_token
+func (s *LarkService) UploadAttachment(appToken string, upload AttachmentUpload) (string, error) {
+	if err := ValidateAttachment(upload); err != nil {
+		return "", err
+	}
+
+	token, err := s.GetTenantAccessToken()
+	if err != nil {
+		return "", fmt.Errorf("获取访问令牌失败: %w", err)
+	}
+
+	realAppToken := s.resolveAppToken(appToken, token)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("file_name", upload.FileName)
+	writer.WriteField("parent_type", "bitable_file")
+	writer.WriteField("parent_node", realAppToken)
+	writer.WriteField("size", strconv.Itoa(len(upload.Data)))
+	part, err := writer.CreateFormFile("file", upload.FileName)
+	if err != nil {
+		return "", fmt.Errorf("构建请求体失败: %w", err)
+	}
+	if _, err := part.Write(upload.Data); err != nil {
+		return "", fmt.Errorf("构建请求体失败: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("构建请求体失败: %w", err)
+	}
+
+	httpReq, err := http.NewRequest("POST", "https://open.feishu.cn/open-apis/drive/v1/medias/upload_all", &body)
+	if err != nil {
+		return "", fmt.Errorf("创建请求失败: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	httpReq.Header.Set("Content-Type", writer.FormDataContentType())
+
+	httpResp, err := s.httpClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("上传附件失败: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return "", fmt.Errorf("读取响应失败: %w", err)
+	}
+	if err := checkLarkAvailable(httpResp); err != nil {
+		return "", fmt.Errorf("上传附件失败: %w", err)
+	}
+
+	type UploadResponse struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			FileToken string `json:"file_token"`
+		} `json:"data"`
+	}
+
+	var result UploadResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	if result.Code != 0 {
+		fmt.Printf("📋 上传附件API响应: %s\n", string(respBody))
+		return "", fmt.Errorf("上传附件失败: %s (Code: %d)", result.Msg, result.Code)
+	}
+
+	fmt.Printf("✅ 附件已上传: %s (%d 字节)\n", upload.FileName, len(upload.Data))
+	return result.Data.FileToken, nil
+}
+
+// PrepareAttachments 上传记录中的附件，并将附件字段替换为 [{"file_token": "..."}] 格式
+// uploads为multipart上传的文件（key为附件字段名）；fields中的附件字段也可以直接提供
+// base64内容 {"name": "a.png", "content": "..."} 或 data URL，已有file_token的附件保持不变
+func (s *LarkService) PrepareAttachments(appToken, tableID string, fields map[string]interface{}, uploads map[string][]AttachmentUpload) error {
+	tableFields, err := s.GetTableFields(appToken, tableID)
+	if err != nil {
+		if len(uploads) == 0 {
+			// 没有上传文件时不阻止写入，字段值按原样提交
+			fmt.Printf("⚠️ 获取表格字段失败，跳过附件处理: %v\n", err)
+			return nil
+		}
+		return fmt.Errorf("获取表格字段失败: %w", err)
+	}
+
+	attachmentFields := make(map[string]bool)
+	for _, field := range tableFields {
+		if fieldKind(field) == "Attachment" {
+			attachmentFields[field.FieldName] = true
+		}
+	}
+
+	for fieldName := range uploads {
+		if !attachmentFields[fieldName] {
+			return fmt.Errorf("%w: 字段 '%s' 不是附件字段", ErrInvalidAttachment, fieldName)
+		}
+	}
+
+	// 先解析并校验所有附件，全部有效后再上传，避免上传一半后失败
+	type pendingAttachment struct {
+		fileToken string
+		upload    *AttachmentUpload
+	}
+	pending := make(map[string][]pendingAttachment)
+	for fieldName := range attachmentFields {
+		var items []pendingAttachment
+
+		value, ok := fields[fieldName]
+		if ok && value != nil {
+			list, isList := value.([]interface{})
+			if !isList {
+				list = []interface{}{value}
+			}
+			for _, item := range list {
+				if m, ok := item.(map[string]interface{}); ok {
+					if fileToken, _ := m["file_token"].(string); fileToken != "" {
+						items = append(items, pendingAttachment{fileToken: fileToken})
+						continue
+					}
+				}
+				upload, err := decodeAttachment(item)
+				if err != nil {
+					return fmt.Errorf("字段 '%s': %w", fieldName, err)
+				}
+				items = append(items, pendingAttachment{upload: &upload})
+			}
+		}
+
+		for i := range uploads[fieldName] {
+			items = append(items, pendingAttachment{upload: &uploads[fieldName][i]})
+		}
+
+		for _, item := range items {
+			if item.upload == nil {
+				continue
+			}
+			if err := ValidateAttachment(*item.upload); err != nil {
+				return fmt.Errorf("字段 '%s': %w", fieldName, err)
+			}
+		}
+		if len(items) > 0 {
+			pending[fieldName] = items
+		}
+	}
+
+	for fieldName, items := range pending {
+		attachments := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			fileToken := item.fileToken
+			if item.upload != nil {
+				fileToken, err = s.UploadAttachment(appToken, *item.upload)
+				if err != nil {
+					return fmt.Errorf("字段 '%s': %w", fieldName, err)
+				}
+			}
+			attachments = append(attachments, map[string]interface{}{"file_token": fileToken})
+		}
+		fields[fieldName] = attachments
+	}
+	return nil
+}
+
+// decodeAttachment 解析base64附件，支持 {"name", "content"} 对象和 data URL 字符串
+func decodeAttachment(item interface{}) (AttachmentUpload, error) {
+	var name, content string
+	switch v := item.(type) {
+	case map[string]interface{}:
+		name, _ = v["name"].(string)
+		content, _ = v["content"].(string)
+	case string:
+		content = v
+	default:
+		return AttachmentUpload{}, fmt.Errorf("%w: 无法识别的附件格式", ErrInvalidAttachment)
+	}
+
+	// data URL: data:image/png;base64,xxxx
+	if strings.HasPrefix(content, "data:") {
+		comma := strings.Index(content, ",")
+		if comma < 0 || !strings.HasSuffix(content[:comma], ";base64") {
+			return AttachmentUpload{}, fmt.Errorf("%w: data URL 必须使用base64编码", ErrInvalidAttachment)
+		}
+		if name == "" {
+			name = "attachment" + extensionForMediaType(strings.TrimSuffix(content[len("data:"):comma], ";base64"))
+		}
+		content = content[comma+1:]
+	}
+	if content == "" {
+		return AttachmentUpload{}, fmt.Errorf("%w: 附件内容为空", ErrInvalidAttachment)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return AttachmentUpload{}, fmt.Errorf("%w: base64内容无效", ErrInvalidAttachment)
+	}
+	return AttachmentUpload{FileName: name, Data: data}, nil
+}
+
+// extensionForMediaType 为没有文件名的data URL生成扩展名
+func extensionForMediaType(mediaType string) string {
+	switch mediaType {
+	case "image/png":
+		return ".png"
+	case "image/jpeg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	case "application/pdf":
+		return ".pdf"
+	case "text/plain":
+		return ".txt"
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SubscribeBitableEvents 订阅多维表格的记录变更事件
+// 每个多维表格只订阅一次，保存解析后的实际token用于匹配事件，订阅成功后飞书会推送 drive.file.bitable_record_changed_v1 事件
+func (s *LarkService) SubscribeBitableEvents(appToken string) error {
+	if _, ok := s.subscribedFiles.Load(appToken); ok {
+		return nil
+	}
+
+	token, err := s.GetTenantAccessToken()
+	if err != nil {
+		return fmt.Errorf("获取访问令牌失败: %w", err)
+	}
+
+	realAppToken := s.resolveAppToken(appToken, token)
+
+	subscribeURL := fmt.Sprintf("https://open.feishu.cn/open-apis/drive/v1/files/%s/subscribe?file_type=bitable", realAppToken)
+	_, body, err := s.handleHTTPRequest("POST", subscribeURL, token, nil)
+	if err != nil {
+		return fmt.Errorf("订阅多维表格事件失败: %w", err)
+	}
+
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	if result.Code != 0 {
+		return fmt.Errorf("订阅多维表格事件失败: %s (Code: %d)", result.Msg, result.Code)
+	}
+
+	s.subscribedFiles.Store(appToken, realAppToken)
+	logInfo("📡 已订阅多维表格 %s 的记录变更事件", realAppToken)
+	return nil
+}
+
+// EventAppTokens 返回记录变更事件中file_token对应的app_token
+// 事件中是多维表格的实际token，检测任务保存的可能是订阅时解析为该多维表格的Wiki节点token
+func (s *LarkService) EventAppTokens(fileToken string) []string {
+	appTokens := []string{fileToken}
+	s.subscribedFiles.Range(func(key, value interface{}) bool {
+		if appToken := key.(string); appToken != fileToken && value == fileToken {
+			appTokens = append(appTokens, appToken)
+		}
+		return true
+	})
+	return appTokens
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"lark-record/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 支持导出的文件格式
+const (
+	ExportFormatCSV   = "csv"
+	ExportFormatXLSX  = "xlsx"
+	ExportFormatJSONL = "jsonl"
+)
+
+// 导出时日期字段使用的本地时间格式
+const exportTimeLayout = "2006-01-02 15:04:05"
+
+// exportRecordIDColumn 导出文件第一列的列名
+const exportRecordIDColumn = "记录ID"
+
+// exportRowWriter 按导出格式写入一行
+type exportRowWriter interface {
+	WriteHeader(columns []string) error
+	WriteRecord(record models.Record, fields []models.Field) error
+	Close() error
+}
+
+// ExportRecords 分页读取数据表的所有记录并写入w
+// CSV和XLSX按字段顺序将复杂字段展开为可读文本，日期按loc输出以便与导入时的解释一致，JSONL每行一条原始记录
+// 获取到第一页记录后才开始写入w，第一页获取失败时w中没有任何内容，调用方仍可以返回错误响应
+func (s *LarkService) ExportRecords(appToken, tableID string, fields []models.Field, format string, loc *time.Location, w io.Writer) (int, error) {
+	query := models.RecordSearchRequest{PageSize: SearchRecordsMaxPageSize}
+	page, err := s.SearchRecords(appToken, tableID, query)
+	if err != nil {
+		return 0, err
+	}
+
+	var writer exportRowWriter
+	switch format {
+	case ExportFormatCSV:
+		writer = newCSVExportWriter(w, loc)
+	case ExportFormatXLSX:
+		xw, err := NewXLSXWriter(w, tableID)
+		if err != nil {
+			return 0, fmt.Errorf("创建xlsx文件失败: %w", err)
+		}
+		writer = &xlsxExportWriter{xw: xw, loc: loc}
+	case ExportFormatJSONL:
+		writer = &jsonlExportWriter{enc: json.NewEncoder(w)}
+	default:
+		return 0, fmt.Errorf("不支持的导出格式: %s", format)
+	}
+
+	columns := []string{exportRecordIDColumn}
+	for _, field := range fields {
+		columns = append(columns, field.FieldName)
+	}
+	if err := writer.WriteHeader(columns); err != nil {
+		return 0, fmt.Errorf("写入导出文件失败: %w", err)
+	}
+
+	count := 0
+	for {
+		for _, record := range page.Items {
+			if err := writer.WriteRecord(record, fields); err != nil {
+				return count, fmt.Errorf("写入导出文件失败: %w", err)
+			}
+			count++
+		}
+
+		if !page.HasMore || page.PageToken == "" {
+			break
+		}
+		query.PageToken = page.PageToken
+		if page, err = s.SearchRecords(appToken, tableID, query); err != nil {
+			return count, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return count, fmt.Errorf("写入导出文件失败: %w", err)
+	}
+	return count, nil
+}
+
+// csvExportWriter CSV导出，写入UTF-8 BOM以便Excel正确识别中文
+type csvExportWriter struct {
+	w   io.Writer
+	cw  *csv.Writer
+	loc *time.Location
+}
+
+func newCSVExportWriter(w io.Writer, loc *time.Location) *csvExportWriter {
+	return &csvExportWriter{w: w, cw: csv.NewWriter(w), loc: loc}
+}
+
+func (e *csvExportWriter) WriteHeader(columns []string) error {
+	if _, err := io.WriteString(e.w, "\ufeff"); err != nil {
+		return err
+	}
+	return e.cw.Write(columns)
+}
+
+func (e *csvExportWriter) WriteRecord(record models.Record, fields []models.Field) error {
+	row := []string{record.RecordID}
+	for _, field := range fields {
+		row = append(row, FlattenFieldValue(field, record.Fields[field.FieldName], e.loc))
+	}
+	return e.cw.Write(row)
+}
+
+func (e *csvExportWriter) Close() error {
+	e.cw.Flush()
+	return e.cw.Error()
+}
+
+// xlsxExportWriter XLSX导出，数字字段写为数字单元格
+type xlsxExportWriter struct {
+	xw  *XLSXWriter
+	loc *time.Location
+}
+
+func (e *xlsxExportWriter) WriteHeader(columns []string) error {
+	cells := make([]XLSXCell, 0, len(columns))
+	for _, column := range columns {
+		cells = append(cells, XLSXCell{Value: column})
+	}
+	return e.xw.WriteRow(cells)
+}
+
+func (e *xlsxExportWriter) WriteRecord(record models.Record, fields []models.Field) error {
+	cells := []XLSXCell{{Value: record.RecordID}}
+	for _, field := range fields {
+		value := record.Fields[field.FieldName]
+		if n, ok := value.(float64); ok && isNumberKind(fieldKind(field)) {
+			cells = append(cells, XLSXCell{Value: strconv.FormatFloat(n, 'f', -1, 64), Number: true})
+			continue
+		}
+		cells = append(cells, XLSXCell{Value: FlattenFieldValue(field, value, e.loc)})
+	}
+	return e.xw.WriteRow(cells)
+}
+
+func (e *xlsxExportWriter) Close() error {
+	return e.xw.Close()
+}
+
+// jsonlExportWriter JSONL导出，每行一条记录，字段值保持飞书返回的原始结构
+type jsonlExportWriter struct {
+	enc *json.Encoder
+}
+
+func (e *jsonlExportWriter) WriteHeader(columns []string) error {
+	return nil
+}
+
+func (e *jsonlExportWriter) WriteRecord(record models.Record, fields []models.Field) error {
+	return e.enc.Encode(record)
+}
+
+func (e *jsonlExportWriter) Close() error {
+	return nil
+}
+
+// FlattenFieldValue 将字段值展开为可读文本
+// 人员显示姓名，多选用逗号分隔，日期转换为loc时区的时间（为nil时使用服务器本地时区），附件输出下载链接
+func FlattenFieldValue(field models.Field, value interface{}, loc *time.Location) string {
+	if isEmptyFieldValue(value) {
+		return ""
+	}
+
+	switch fieldKind(field) {
+	case "DateTime", "CreatedTime", "ModifiedTime":
+		if ms, ok := fieldValueNumber(value); ok {
+			if loc == nil {
+				loc = time.Local
+			}
+			return time.UnixMilli(int64(ms)).In(loc).Format(exportTimeLayout)
+		}
+	case "Attachment":
+		if items, ok := value.([]interface{}); ok {
+			var urls []string
+			for _, item := range items {
+				if m, ok := item.(map[string]interface{}); ok {
+					if url, _ := m["url"].(string); url != "" {
+						urls = append(urls, url)
+					} else if name, _ := m["name"].(string); name != "" {
+						urls = append(urls, name)
+					}
+				}
+			}
+			return strings.Join(urls, "\n")
+		}
+	case "Url":
+		if m, ok := value.(map[string]interface{}); ok {
+			if link, _ := m["link"].(string); link != "" {
+				return link
+			}
+		}
+	case "Location":
+		if m, ok := value.(map[string]interface{}); ok {
+			if address, _ := m["full_address"].(string); address != "" {
+				return address
+			}
+		}
+	case "Checkbox":
+		if b, ok := value.(bool); ok {
+			if b {
+				return "是"
+			}
+			return "否"
+		}
+	}
+
+	return strings.Join(fieldValueTexts(value), ", ")
+}
+
+// isNumberKind 判断字段类型是否为数字类
+func isNumberKind(kind string) bool {
+	switch kind {
+	case "Number", "Currency", "Progress", "Rating", "AutoNumber":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"lark-record/models"
+	"strings"
+)
+
+// ImportRecords 将导入文件中的数据按列映射写入数据表
+// mapping为列名到字段名的映射，为空时按同名列匹配字段；dryRun为true时按写入流程校验每一行但不写入，
+// 不会新建选项和关联记录
+func (s *LarkService) ImportRecords(appToken, tableID string, rows []ImportRow, mapping map[string]string, dryRun bool, opts RecordOptions) (*models.ImportReport, error) {
+	tableFields, err := s.GetTableFields(appToken, tableID)
+	if err != nil {
+		return nil, fmt.Errorf("获取表格字段失败: %w", err)
+	}
+
+	fieldsByName := make(map[string]models.Field)
+	for _, field := range tableFields {
+		fieldsByName[field.FieldName] = field
+	}
+
+	if len(mapping) == 0 {
+		mapping = defaultImportMapping(rows, fieldsByName)
+		if len(mapping) == 0 {
+			return nil, fmt.Errorf("文件中没有与表格字段同名的列，请指定列映射")
+		}
+	}
+	for column, fieldName := range mapping {
+		if _, ok := fieldsByName[fieldName]; !ok {
+			return nil, fmt.Errorf("列 '%s' 映射的字段 '%s' 不存在", column, fieldName)
+		}
+	}
+
+	report := &models.ImportReport{
+		DryRun:    dryRun,
+		Total:     len(rows),
+		RecordIDs: []string{},
+		Errors:    []models.ImportRowError{},
+	}
+
+	// 只校验时按写入流程处理选项策略、人员邮箱和关联记录，但不新建选项和关联记录
+	opts.DryRun = dryRun
+	var token, realAppToken string
+	if dryRun {
+		token, err = s.GetTenantAccessToken()
+		if err != nil {
+			return nil, fmt.Errorf("获取访问令牌失败: %w", err)
+		}
+		realAppToken = s.resolveAppToken(appToken, token)
+	}
+
+	// 开启严格校验的数据表与单条新增一致，检查必填字段和选项
+	var required []string
+	if opts.Strict {
+		for _, field := range opts.WriteFields {
+			if field.Required {
+				required = append(required, field.FieldName)
+			}
+		}
+	}
+
+	// 逐行转换字段值，有错误的行不写入
+	var records []map[string]interface{}
+	var lines []int
+	for _, row := range rows {
+		fields := make(map[string]interface{})
+		var rowErrs FieldValueErrors
+		for column, fieldName := range mapping {
+			raw, ok := row.Values[column]
+			if !ok || raw == nil {
+				continue
+			}
+			if text, isText := raw.(string); isText && strings.TrimSpace(text) == "" {
+				continue
+			}
+
+			value, err := EncodeFieldValue(fieldsByName[fieldName], raw, opts.location())
+			if err != nil {
+				rowErrs = append(rowErrs, err.(*FieldValueError))
+				continue
+			}
+			fields[fieldName] = value
+		}
+		if len(rowErrs) == 0 && opts.Strict {
+			rowErrs = ValidateRecordFields(tableFields, fields, required, opts)
+		}
+
+		if len(rowErrs) > 0 {
+			for _, fieldErr := range rowErrs {
+				report.Errors = append(report.Errors, models.ImportRowError{Row: row.Line, Field: fieldErr.Field, Error: fieldErr.Message})
+			}
+			report.Failed++
+			continue
+		}
+		if len(fields) == 0 {
+			report.Errors = append(report.Errors, models.ImportRowError{Row: row.Line, Error: "没有可写入的字段"})
+			report.Failed++
+			continue
+		}
+
+		if dryRun {
+			if _, err := s.encodeFields(realAppToken, tableID, token, fields, opts); err != nil {
+				var fieldErrs FieldValueErrors
+				if errors.As(err, &fieldErrs) {
+					for _, fieldErr := range fieldErrs {
+						report.Errors = append(report.Errors, models.ImportRowError{Row: row.Line, Field: fieldErr.Field, Error: fieldErr.Message})
+					}
+				} else {
+					report.Errors = append(report.Errors, models.ImportRowError{Row: row.Line, Error: err.Error()})
+				}
+				report.Failed++
+				continue
+			}
+		}
+		records = append(records, fields)
+		lines = append(lines, row.Line)
+	}
+	report.Valid = len(records)
+
+	if dryRun || len(records) == 0 {
+		return report, nil
+	}
+
+	fmt.Printf("📥 开始导入 %d 条记录 - AppToken: %s, TableID: %s\n", len(records), appToken, tableID)
+	for i, result := range s.BatchAddRecords(appToken, tableID, records, opts) {
+		if result.Error != "" {
+			report.Errors = append(report.Errors, models.ImportRowError{Row: lines[i], Error: result.Error})
+			report.Failed++
+			continue
+		}
+		report.Created++
+		report.RecordIDs = append(report.RecordIDs, result.RecordID)
+	}
+	fmt.Printf("✅ 导入完成: 成功 %d 条，失败 %d 条\n", report.Created, report.Failed)
+
+	return report, nil
+}
+
+// defaultImportMapping 为与字段同名的列生成映射
+func defaultImportMapping(rows []ImportRow, fieldsByName map[string]models.Field) map[string]string {
+	mapping := make(map[string]string)
+	for _, row := range rows {
+		for column := range row.Values {
+			if _, ok := fieldsByName[column]; ok {
+				mapping[column] = column
+			}
+		}
+	}
+	return mapping
+}
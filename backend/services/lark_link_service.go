@@ -0,0 +1,175 @@
+package services
+
+import (
+	"fmt"
+	"lark-record/models"
+	"regexp"
+	"strings"
+)
+
+// linkLookupBatchSize 按主字段查找关联记录时每次查询的值数量
+const linkLookupBatchSize = 20
+
+// recordIDPattern 飞书记录ID的格式
+var recordIDPattern = regexp.MustCompile(`^rec[0-9A-Za-z]{6,}$`)
+
+// resolveLinkRecords 将关联字段中填写的值转换为关联表的记录ID
+// 按关联表主字段的值查找记录；找不到时记录ID格式的值按记录ID写入，字段配置了自动新建时在关联表中新建记录
+func (s *LarkService) resolveLinkRecords(appToken, token string, tableFields []models.Field, fields map[string]interface{}, opts RecordOptions) error {
+	var errs FieldValueErrors
+	for _, field := range tableFields {
+		kind := fieldKind(field)
+		if kind != "SingleLink" && kind != "DuplexLink" {
+			continue
+		}
+		values, ok := fields[field.FieldName].([]string)
+		if !ok || len(values) == 0 {
+			continue
+		}
+
+		createMissing := opts.writeField(field.FieldName).CreateMissingLinks
+		recordIDs, fieldErrs, err := s.lookupLinkRecords(appToken, token, field, values, createMissing, opts)
+		if err != nil {
+			return err
+		}
+		if len(fieldErrs) > 0 {
+			errs = append(errs, fieldErrs...)
+			continue
+		}
+		fields[field.FieldName] = recordIDs
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// lookupLinkRecords 查找一个关联字段中所有值对应的记录ID，所有值都能确定后才新建缺少的记录
+func (s *LarkService) lookupLinkRecords(appToken, token string, field models.Field, values []string, createMissing bool, opts RecordOptions) ([]string, FieldValueErrors, error) {
+	fail := func(code, format string, args ...interface{}) *FieldValueError {
+		return &FieldValueError{Field: field.FieldName, Code: code, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if field.LinkTableID == "" {
+		// 无法确定关联表时只接受记录ID
+		for _, value := range values {
+			if !recordIDPattern.MatchString(value) {
+				return nil, FieldValueErrors{fail(FieldErrUnknownLink, "无法获取关联表，请填写记录ID: %s", value)}, nil
+			}
+		}
+		return values, nil, nil
+	}
+
+	linkFields, err := s.GetTableFieldsWithToken(appToken, field.LinkTableID, token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取关联表字段失败: %w", err)
+	}
+	var primary *models.Field
+	for i := range linkFields {
+		if linkFields[i].IsPrimary {
+			primary = &linkFields[i]
+			break
+		}
+	}
+	if primary == nil {
+		return nil, nil, fmt.Errorf("关联表 %s 没有主字段", field.LinkTableID)
+	}
+
+	matches, err := s.findRecordsByPrimary(appToken, field.LinkTableID, *primary, values, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var errs FieldValueErrors
+	var missing []string
+	for _, value := range values {
+		found := matches[value]
+		switch {
+		case len(found) == 1:
+		case len(found) > 1:
+			errs = append(errs, fail(FieldErrAmbiguousLink, "关联表中有 %d 条记录的%s为 '%s'", len(found), primary.FieldName, value))
+		case recordIDPattern.MatchString(value):
+			matches[value] = []string{value}
+		case createMissing:
+			missing = append(missing, value)
+		default:
+			errs = append(errs, fail(FieldErrUnknownLink, "关联表中没有%s为 '%s' 的记录", primary.FieldName, value))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs, nil
+	}
+	if opts.DryRun && len(missing) > 0 {
+		// 只校验时不新建缺少的记录
+		return nil, nil, nil
+	}
+
+	for _, value := range missing {
+		if len(matches[value]) > 0 {
+			// 同一个值填写了多次
+			continue
+		}
+		// 关联表的字段不使用当前数据表的写入配置
+		recordID, err := s.AddRecord(appToken, field.LinkTableID, map[string]interface{}{primary.FieldName: value}, RecordOptions{Location: opts.Location})
+		if err != nil {
+			return nil, nil, fmt.Errorf("在关联表中新建记录 '%s' 失败: %w", value, err)
+		}
+		fmt.Printf("✅ 已在关联表 %s 中新建记录 '%s'，记录ID: %s\n", field.LinkTableID, value, recordID)
+		matches[value] = []string{recordID}
+	}
+
+	recordIDs := make([]string, 0, len(values))
+	seen := make(map[string]bool)
+	for _, value := range values {
+		recordID := matches[value][0]
+		if !seen[recordID] {
+			seen[recordID] = true
+			recordIDs = append(recordIDs, recordID)
+		}
+	}
+	return recordIDs, nil, nil
+}
+
+// findRecordsByPrimary 在数据表中查找主字段值与values相同的记录，返回值到记录ID列表的映射
+func (s *LarkService) findRecordsByPrimary(appToken, tableID string, primary models.Field, values []string, opts RecordOptions) (map[string][]string, error) {
+	matches := make(map[string][]string)
+	for start := 0; start < len(values); start += linkLookupBatchSize {
+		end := start + linkLookupBatchSize
+		if end > len(values) {
+			end = len(values)
+		}
+
+		wanted := make(map[string]bool)
+		filter := models.ConditionGroup{Conjunction: models.ConjunctionOr}
+		for _, value := range values[start:end] {
+			if wanted[value] {
+				continue
+			}
+			wanted[value] = true
+			filter.Conditions = append(filter.Conditions, models.Condition{
+				FieldName: primary.FieldName,
+				Operator:  models.OperatorIs,
+				Value:     []string{value},
+			})
+		}
+
+		records, err := s.SearchAllRecords(appToken, tableID, models.RecordSearchRequest{
+			FieldNames: []string{primary.FieldName},
+			Filter:     &filter,
+			PageSize:   SearchRecordsMaxPageSize,
+		}, 0)
+		if err != nil {
+			return nil, fmt.Errorf("查找关联记录失败: %w", err)
+		}
+
+		// 查询接口对部分字段类型是模糊匹配，在本地按显示文本精确匹配
+		for _, record := range records {
+			text := strings.TrimSpace(FlattenFieldValue(primary, record.Fields[primary.FieldName], opts.location()))
+			if wanted[text] {
+				matches[text] = append(matches[text], record.RecordID)
+			}
+		}
+	}
+	return matches, nil
+}
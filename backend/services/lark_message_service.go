@@ -22,8 +22,8 @@ func NewLarkMessageService(appID, appSecret string) *LarkMessageService {
 	}
 }
 
-// SendMessage 发送消息到指定群聊
-func (s *LarkMessageService) SendMessage(groupChatID, message string) error {
+// SendMessage 发送消息到指定群聊，返回消息ID
+func (s *LarkMessageService) SendMessage(groupChatID, message string) (string, error) {
 	ctx := context.Background()
 
 	// 构建消息内容
@@ -52,7 +52,7 @@ func (s *LarkMessageService) SendMessage(groupChatID, message string) error {
 	resp, err := s.client.Im.Message.Create(ctx, req)
 	if err != nil {
 		log.Printf("❌ 发送消息失败: %v", err)
-		return fmt.Errorf("发送消息失败: %v", err)
+		return "", fmt.Errorf("发送消息失败: %v", err)
 	}
 
 	if !resp.Success() {
@@ -60,14 +60,37 @@ func (s *LarkMessageService) SendMessage(groupChatID, message string) error {
 		// 输出完整的响应信息以帮助诊断
 		respBytes, _ := json.Marshal(resp)
 		log.Printf("📋 完整响应: %s", string(respBytes))
-		return fmt.Errorf("发送消息失败: %s (Code: %d)", resp.Msg, resp.Code)
+		return "", fmt.Errorf("发送消息失败: %s (Code: %d)", resp.Msg, resp.Code)
 	}
 
 	// 输出发送成功的信息
 	log.Printf("✅ 消息发送成功!")
+	messageID := ""
 	if resp.Data != nil && resp.Data.MessageId != nil && *resp.Data.MessageId != "" {
-		log.Printf("📄 消息ID: %s", *resp.Data.MessageId)
+		messageID = *resp.Data.MessageId
+		log.Printf("📄 消息ID: %s", messageID)
 	}
 
+	return messageID, nil
+}
+
+// RecallMessage 撤回机器人发送的消息
+func (s *LarkMessageService) RecallMessage(messageID string) error {
+	req := larkim.NewDeleteMessageReqBuilder().
+		MessageId(messageID).
+		Build()
+
+	resp, err := s.client.Im.Message.Delete(context.Background(), req)
+	if err != nil {
+		log.Printf("❌ 撤回消息失败: %v", err)
+		return fmt.Errorf("撤回消息失败: %v", err)
+	}
+
+	if !resp.Success() {
+		log.Printf("❌ 撤回消息失败: %s (Code: %d)", resp.Msg, resp.Code)
+		return fmt.Errorf("撤回消息失败: %s (Code: %d)", resp.Msg, resp.Code)
+	}
+
+	log.Printf("✅ 消息 %s 已撤回", messageID)
 	return nil
 }
\ No newline at end of file
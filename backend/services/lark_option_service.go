@@ -0,0 +1,219 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"lark-record/models"
+	"strings"
+)
+
+// applyOptionPolicies 按字段配置的选项策略处理单选/多选字段中不在选项列表里的值
+// reject 返回字段错误，create 写入前在字段中新增选项，other 替换为"其他"选项
+func (s *LarkService) applyOptionPolicies(appToken, tableID, token string, tableFields []models.Field, fields map[string]interface{}, opts RecordOptions) error {
+	var errs FieldValueErrors
+	for _, field := range tableFields {
+		kind := fieldKind(field)
+		if kind != "SingleSelect" && kind != "MultiSelect" {
+			continue
+		}
+		policy := opts.writeField(field.FieldName).OptionPolicy
+		if policy == "" {
+			continue
+		}
+
+		var values []string
+		switch v := fields[field.FieldName].(type) {
+		case string:
+			values = []string{v}
+		case []string:
+			values = v
+		default:
+			continue
+		}
+
+		existing := make(map[string]bool, len(field.Options))
+		for _, option := range field.Options {
+			existing[option] = true
+		}
+		var unknown []string
+		for _, value := range values {
+			if !existing[value] {
+				unknown = append(unknown, value)
+			}
+		}
+		if len(unknown) == 0 {
+			continue
+		}
+
+		switch policy {
+		case models.OptionPolicyReject:
+			errs = append(errs, &FieldValueError{Field: field.FieldName, Code: FieldErrInvalidOption, Message: fmt.Sprintf("'%s' 不是有效的选项", strings.Join(unknown, "、"))})
+
+		case models.OptionPolicyCreate:
+			if opts.DryRun {
+				continue
+			}
+			if err := s.addFieldOptions(appToken, tableID, token, field, unknown); err != nil {
+				return err
+			}
+
+		case models.OptionPolicyOther:
+			if !existing[models.OtherOptionName] && !opts.DryRun {
+				if err := s.addFieldOptions(appToken, tableID, token, field, []string{models.OtherOptionName}); err != nil {
+					return err
+				}
+			}
+			if kind == "SingleSelect" {
+				fields[field.FieldName] = models.OtherOptionName
+				continue
+			}
+			mapped := make([]string, 0, len(values))
+			seen := make(map[string]bool)
+			for _, value := range values {
+				if !existing[value] {
+					value = models.OtherOptionName
+				}
+				if !seen[value] {
+					seen[value] = true
+					mapped = append(mapped, value)
+				}
+			}
+			fields[field.FieldName] = mapped
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// addFieldOptions 在单选/多选字段中新增选项，保留字段已有的选项和其他属性
+// 更新成功后清除该数据表的字段缓存
+func (s *LarkService) addFieldOptions(appToken, tableID, token string, field models.Field, names []string) error {
+	fieldType, property, err := s.getFieldProperty(appToken, tableID, token, field.FieldID)
+	if err != nil {
+		return err
+	}
+
+	options, _ := property["options"].([]interface{})
+	existing := make(map[string]bool, len(options))
+	for _, option := range options {
+		if m, ok := option.(map[string]interface{}); ok {
+			if name, _ := m["name"].(string); name != "" {
+				existing[name] = true
+			}
+		}
+	}
+	var added []string
+	for _, name := range names {
+		// 字段缓存可能已过时，其他用户可能已添加了该选项
+		if !existing[name] {
+			existing[name] = true
+			options = append(options, map[string]interface{}{"name": name})
+			added = append(added, name)
+		}
+	}
+	if len(added) == 0 {
+		s.invalidateFieldsCache(tableID)
+		return nil
+	}
+	property["options"] = options
+
+	reqBodyBytes, err := json.Marshal(map[string]interface{}{
+		"field_name": field.FieldName,
+		"type":       fieldType,
+		"property":   property,
+	})
+	if err != nil {
+		return fmt.Errorf("构建请求体失败: %w", err)
+	}
+
+	updateURL := fmt.Sprintf("https://open.feishu.cn/open-apis/bitable/v1/apps/%s/tables/%s/fields/%s", appToken, tableID, field.FieldID)
+	_, body, err := s.handleHTTPRequest("PUT", updateURL, token, reqBodyBytes)
+	if err != nil {
+		return fmt.Errorf("新增字段选项失败: %w", err)
+	}
+
+	type UpdateFieldResponse struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+
+	var result UpdateFieldResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	if result.Code != 0 {
+		fmt.Printf("📋 更新字段API响应: %s\n", string(body))
+		return fmt.Errorf("新增字段选项失败: %s (Code: %d)", result.Msg, result.Code)
+	}
+
+	s.invalidateFieldsCache(tableID)
+	fmt.Printf("✅ 字段 '%s' 已新增选项: %v\n", field.FieldName, added)
+	return nil
+}
+
+// getFieldProperty 获取字段当前的类型和完整属性（包括选项ID和颜色），不使用字段缓存
+func (s *LarkService) getFieldProperty(appToken, tableID, token, fieldID string) (int, map[string]interface{}, error) {
+	type ListFieldsResponse struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			HasMore   bool   `json:"has_more"`
+			PageToken string `json:"page_token"`
+			Items     []struct {
+				FieldID  string                 `json:"field_id"`
+				Type     int                    `json:"type"`
+				Property map[string]interface{} `json:"property"`
+			} `json:"items"`
+		} `json:"data"`
+	}
+
+	pageToken := ""
+	for {
+		fieldsURL := fmt.Sprintf("https://open.feishu.cn/open-apis/bitable/v1/apps/%s/tables/%s/fields?page_size=100", appToken, tableID)
+		if pageToken != "" {
+			fieldsURL += "&page_token=" + pageToken
+		}
+		_, body, err := s.handleHTTPRequest("GET", fieldsURL, token, nil)
+		if err != nil {
+			return 0, nil, fmt.Errorf("获取字段失败: %w", err)
+		}
+
+		var result ListFieldsResponse
+		if err := json.Unmarshal(body, &result); err != nil {
+			return 0, nil, fmt.Errorf("解析响应失败: %w", err)
+		}
+		if result.Code != 0 {
+			return 0, nil, fmt.Errorf("获取字段失败: %s (Code: %d)", result.Msg, result.Code)
+		}
+
+		for _, item := range result.Data.Items {
+			if item.FieldID == fieldID {
+				if item.Property == nil {
+					item.Property = make(map[string]interface{})
+				}
+				return item.Type, item.Property, nil
+			}
+		}
+
+		if !result.Data.HasMore || result.Data.PageToken == "" {
+			return 0, nil, fmt.Errorf("字段 %s 不存在", fieldID)
+		}
+		pageToken = result.Data.PageToken
+	}
+}
+
+// invalidateFieldsCache 清除数据表的字段缓存（包括以Wiki Token缓存的条目）
+func (s *LarkService) invalidateFieldsCache(tableID string) {
+	suffix := ":" + tableID
+	s.fieldsCache.Range(func(key, value interface{}) bool {
+		if k, ok := key.(string); ok && strings.HasSuffix(k, suffix) {
+			s.fieldsCache.Delete(key)
+			s.fieldsCacheTime.Delete(key)
+		}
+		return true
+	})
+}
@@ -0,0 +1,605 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"lark-record/models"
+	"net/url"
+	"strings"
+)
+
+// 记录查询配置
+const (
+	SearchRecordsMaxPageSize  = 500 // 查询记录接口单页最大数量
+	BatchGetRecordsMaxSize    = 100 // 批量获取记录接口单次最大数量
+	BatchCreateRecordsMaxSize = 500 // 批量新增记录接口单次最大数量
+)
+
+// searchCondition 飞书查询接口的筛选条件，isEmpty/isNotEmpty也需要传空数组
+type searchCondition struct {
+	FieldName string   `json:"field_name"`
+	Operator  string   `json:"operator"`
+	Value     []string `json:"value"`
+}
+
+// SearchRecords 查询数据表中的记录（单页）
+func (s *LarkService) SearchRecords(appToken, tableID string, query models.RecordSearchRequest) (*models.RecordPage, error) {
+	token, err := s.GetTenantAccessToken()
+	if err != nil {
+		return nil, fmt.Errorf("获取访问令牌失败: %w", err)
+	}
+
+	realAppToken := s.resolveAppToken(appToken, token)
+
+	pageSize := query.PageSize
+	if pageSize <= 0 || pageSize > SearchRecordsMaxPageSize {
+		pageSize = SearchRecordsMaxPageSize
+	}
+
+	params := url.Values{}
+	params.Set("user_id_type", "user_id")
+	params.Set("page_size", fmt.Sprintf("%d", pageSize))
+	if query.PageToken != "" {
+		params.Set("page_token", query.PageToken)
+	}
+	searchURL := fmt.Sprintf("https://open.feishu.cn/open-apis/bitable/v1/apps/%s/tables/%s/records/search?%s", realAppToken, tableID, params.Encode())
+
+	// 构建请求体，返回创建时间和更新时间等自动字段
+	reqBody := map[string]interface{}{
+		"automatic_fields": true,
+	}
+	if query.ViewID != "" {
+		reqBody["view_id"] = query.ViewID
+	}
+	if len(query.FieldNames) > 0 {
+		reqBody["field_names"] = query.FieldNames
+	}
+	if query.Filter != nil && len(query.Filter.Conditions) > 0 {
+		reqBody["filter"] = buildSearchFilter(*query.Filter)
+	}
+	if len(query.Sort) > 0 {
+		reqBody["sort"] = query.Sort
+	}
+
+	reqBodyBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("构建请求体失败: %w", err)
+	}
+
+	_, body, err := s.handleHTTPRequest("POST", searchURL, token, reqBodyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("查询记录失败: %w", err)
+	}
+
+	type SearchRecordsResponse struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			Items []struct {
+				RecordID         string                 `json:"record_id"`
+				Fields           map[string]interface{} `json:"fields"`
+				CreatedTime      int64                  `json:"created_time"`
+				LastModifiedTime int64                  `json:"last_modified_time"`
+			} `json:"items"`
+			HasMore   bool   `json:"has_more"`
+			PageToken string `json:"page_token"`
+			Total     int    `json:"total"`
+		} `json:"data"`
+	}
+
+	var result SearchRecordsResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	if result.Code != 0 {
+		fmt.Printf("📋 查询记录API响应: %s\n", string(body))
+		return nil, fmt.Errorf("查询记录失败: %s (Code: %d)", result.Msg, result.Code)
+	}
+
+	page := &models.RecordPage{
+		Items:     []models.Record{},
+		Total:     result.Data.Total,
+		HasMore:   result.Data.HasMore,
+		PageToken: result.Data.PageToken,
+	}
+	for _, item := range result.Data.Items {
+		page.Items = append(page.Items, models.Record{
+			RecordID:         item.RecordID,
+			Fields:           item.Fields,
+			CreatedTime:      item.CreatedTime,
+			LastModifiedTime: item.LastModifiedTime,
+		})
+	}
+	return page, nil
+}
+
+// SearchAllRecords 分页查询所有符合条件的记录，limit大于0时最多返回limit条
+func (s *LarkService) SearchAllRecords(appToken, tableID string, query models.RecordSearchRequest, limit int) ([]models.Record, error) {
+	var records []models.Record
+	query.PageToken = ""
+
+	for {
+		page, err := s.SearchRecords(appToken, tableID, query)
+		if err != nil {
+			return records, err
+		}
+
+		records = append(records, page.Items...)
+		if limit > 0 && len(records) >= limit {
+			return records[:limit], nil
+		}
+		if !page.HasMore || page.PageToken == "" {
+			return records, nil
+		}
+		query.PageToken = page.PageToken
+	}
+}
+
+// BatchGetRecords 批量获取记录的字段，超过单次上限时自动分批请求
+// 返回以记录ID为key的字段值，以及已不存在或无权限访问的记录ID
+func (s *LarkService) BatchGetRecords(appToken, tableID string, recordIDs []string) (map[string]map[string]interface{}, []string, error) {
+	token, err := s.GetTenantAccessToken()
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取访问令牌失败: %w", err)
+	}
+
+	realAppToken := s.resolveAppToken(appToken, token)
+	batchURL := fmt.Sprintf("https://open.feishu.cn/open-apis/bitable/v1/apps/%s/tables/%s/records/batch_get", realAppToken, tableID)
+
+	type BatchGetRecordsResponse struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			Records []struct {
+				RecordID string                 `json:"record_id"`
+				Fields   map[string]interface{} `json:"fields"`
+			} `json:"records"`
+			AbsentRecordIDs    []string `json:"absent_record_ids"`
+			ForbiddenRecordIDs []string `json:"forbidden_record_ids"`
+		} `json:"data"`
+	}
+
+	records := make(map[string]map[string]interface{})
+	var missing []string
+	for start := 0; start < len(recordIDs); start += BatchGetRecordsMaxSize {
+		end := start + BatchGetRecordsMaxSize
+		if end > len(recordIDs) {
+			end = len(recordIDs)
+		}
+
+		reqBodyBytes, err := json.Marshal(map[string]interface{}{
+			"record_ids":   recordIDs[start:end],
+			"user_id_type": "user_id",
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("构建请求体失败: %w", err)
+		}
+
+		_, body, err := s.handleHTTPRequest("POST", batchURL, token, reqBodyBytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("批量获取记录失败: %w", err)
+		}
+
+		var result BatchGetRecordsResponse
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, nil, fmt.Errorf("解析响应失败: %w", err)
+		}
+
+		if result.Code != 0 {
+			fmt.Printf("📋 批量获取记录API响应: %s\n", string(body))
+			return nil, nil, fmt.Errorf("批量获取记录失败: %s (Code: %d)", result.Msg, result.Code)
+		}
+
+		for _, record := range result.Data.Records {
+			records[record.RecordID] = record.Fields
+		}
+		missing = append(missing, result.Data.AbsentRecordIDs...)
+		missing = append(missing, result.Data.ForbiddenRecordIDs...)
+	}
+
+	return records, missing, nil
+}
+
+// BatchAddRecords 批量新增记录，按接口上限分批写入
+// 飞书拒绝整批写入时逐条重新写入，以便返回每条记录各自的错误；
+// 网络错误等无法确定是否已写入的失败不再重试，避免重复新增
+func (s *LarkService) BatchAddRecords(appToken, tableID string, records []map[string]interface{}, opts RecordOptions) []models.BatchRecordResult {
+	results := make([]models.BatchRecordResult, len(records))
+	for i := range results {
+		results[i].Index = i
+	}
+
+	token, err := s.GetTenantAccessToken()
+	if err != nil {
+		for i := range results {
+			results[i].Error = fmt.Sprintf("获取访问令牌失败: %v", err)
+		}
+		return results
+	}
+
+	realAppToken := s.resolveAppToken(appToken, token)
+
+	// 与单条新增一致，按字段类型编码字段值，编码失败的记录不写入
+	var pending []map[string]interface{}
+	var indexes []int
+	for i, fields := range records {
+		encoded, err := s.encodeFields(realAppToken, tableID, token, fields, opts)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		pending = append(pending, encoded)
+		indexes = append(indexes, i)
+	}
+
+	for start := 0; start < len(pending); start += BatchCreateRecordsMaxSize {
+		end := start + BatchCreateRecordsMaxSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+
+		recordIDs, err := s.batchCreateRecords(realAppToken, tableID, token, pending[start:end])
+		if err == nil {
+			for i, recordID := range recordIDs {
+				results[indexes[start+i]].RecordID = recordID
+			}
+			continue
+		}
+
+		// 飞书返回错误码时整批都未写入，其他错误下记录可能已经写入
+		if LarkErrorCode(err) <= 0 {
+			fmt.Printf("❌ 批量新增第 %d-%d 条记录失败，无法确定是否已写入: %v\n", start+1, end, err)
+			for i := start; i < end; i++ {
+				results[indexes[i]].Error = fmt.Sprintf("%v（无法确定是否已写入，请确认后再重试）", err)
+			}
+			continue
+		}
+
+		fmt.Printf("⚠️ 批量新增第 %d-%d 条记录失败，改为逐条新增: %v\n", start+1, end, err)
+		for i := start; i < end; i++ {
+			recordID, err := s.addEncodedRecord(realAppToken, tableID, token, pending[i])
+			if err != nil {
+				results[indexes[i]].Error = err.Error()
+				continue
+			}
+			results[indexes[i]].RecordID = recordID
+		}
+	}
+
+	return results
+}
+
+// batchCreateRecords 调用批量新增接口写入一批记录，返回与输入顺序一致的记录ID
+func (s *LarkService) batchCreateRecords(appToken, tableID, token string, records []map[string]interface{}) ([]string, error) {
+	createURL := fmt.Sprintf("https://open.feishu.cn/open-apis/bitable/v1/apps/%s/tables/%s/records/batch_create?user_id_type=user_id", appToken, tableID)
+
+	items := make([]map[string]interface{}, 0, len(records))
+	for _, fields := range records {
+		items = append(items, map[string]interface{}{"fields": fields})
+	}
+
+	reqBodyBytes, err := json.Marshal(map[string]interface{}{"records": items})
+	if err != nil {
+		return nil, fmt.Errorf("构建请求体失败: %w", err)
+	}
+
+	_, body, err := s.handleHTTPRequest("POST", createURL, token, reqBodyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("批量新增记录失败: %w", err)
+	}
+
+	type BatchCreateRecordsResponse struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			Records []struct {
+				RecordID string `json:"record_id"`
+			} `json:"records"`
+		} `json:"data"`
+	}
+
+	var result BatchCreateRecordsResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	if result.Code != 0 {
+		fmt.Printf("📋 批量新增记录API响应: %s\n", string(body))
+		return nil, fmt.Errorf("批量新增记录失败: %s (Code: %d)", result.Msg, result.Code)
+	}
+
+	if len(result.Data.Records) != len(records) {
+		return nil, fmt.Errorf("批量新增记录失败: 返回记录数 %d 与请求数 %d 不一致", len(result.Data.Records), len(records))
+	}
+
+	recordIDs := make([]string, 0, len(records))
+	for _, record := range result.Data.Records {
+		recordIDs = append(recordIDs, record.RecordID)
+	}
+	return recordIDs, nil
+}
+
+// FindRecordByKey 查找唯一键字段值与提交内容相同的已存在记录，未找到时返回空字符串
+// 提交内容缺少任一唯一键字段时无法判断是否重复，同样返回空字符串
+func (s *LarkService) FindRecordByKey(appToken, tableID string, keyFields []string, fields map[string]interface{}) (string, error) {
+	if len(keyFields) == 0 {
+		return "", nil
+	}
+
+	filter := models.ConditionGroup{Conjunction: models.ConjunctionAnd}
+	for _, fieldName := range keyFields {
+		value := fields[fieldName]
+		if isEmptyFieldValue(value) {
+			return "", nil
+		}
+		filter.Conditions = append(filter.Conditions, models.Condition{
+			FieldName: fieldName,
+			Operator:  models.OperatorIs,
+			Value:     fieldValueTexts(value),
+		})
+	}
+
+	page, err := s.SearchRecords(appToken, tableID, models.RecordSearchRequest{
+		FieldNames: keyFields,
+		Filter:     &filter,
+		PageSize:   10,
+	})
+	if err != nil {
+		return "", fmt.Errorf("查找重复记录失败: %w", err)
+	}
+
+	// 查询接口对部分字段类型是模糊匹配，在本地再确认一次字段值完全相同
+	for _, record := range page.Items {
+		if EvaluateConditions(filter, record.Fields) {
+			return record.RecordID, nil
+		}
+	}
+	return "", nil
+}
+
+// UpdateRecord 更新记录的字段值，返回更新后的记录字段
+// 飞书更新接口只修改请求中提供的字段，未提供的字段保持不变
+func (s *LarkService) UpdateRecord(appToken, tableID, recordID string, fields map[string]interface{}, opts RecordOptions) (map[string]interface{}, error) {
+	token, err := s.GetTenantAccessToken()
+	if err != nil {
+		return nil, fmt.Errorf("获取访问令牌失败: %w", err)
+	}
+
+	realAppToken := s.resolveAppToken(appToken, token)
+
+	// 与新增记录一致，按字段类型编码字段值
+	fields, err = s.encodeFields(realAppToken, tableID, token, fields, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	reqBodyBytes, err := json.Marshal(map[string]interface{}{"fields": fields})
+	if err != nil {
+		return nil, fmt.Errorf("构建请求体失败: %w", err)
+	}
+
+	fmt.Printf("📋 准备更新记录 - AppToken: %s, TableID: %s, RecordID: %s\n", realAppToken, tableID, recordID)
+	updateURL := fmt.Sprintf("https://open.feishu.cn/open-apis/bitable/v1/apps/%s/tables/%s/records/%s?user_id_type=user_id", realAppToken, tableID, recordID)
+	_, body, err := s.handleHTTPRequest("PUT", updateURL, token, reqBodyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("更新记录失败: %w", err)
+	}
+
+	type UpdateRecordResponse struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			Record struct {
+				Fields map[string]interface{} `json:"fields"`
+			} `json:"record"`
+		} `json:"data"`
+	}
+
+	var result UpdateRecordResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	if result.Code != 0 {
+		fmt.Printf("📋 更新记录API响应: %s\n", string(body))
+		return nil, fmt.Errorf("更新记录失败: %s (Code: %d)", result.Msg, result.Code)
+	}
+
+	return result.Data.Record.Fields, nil
+}
+
+// DeleteRecord 删除记录
+func (s *LarkService) DeleteRecord(appToken, tableID, recordID string) error {
+	token, err := s.GetTenantAccessToken()
+	if err != nil {
+		return fmt.Errorf("获取访问令牌失败: %w", err)
+	}
+
+	realAppToken := s.resolveAppToken(appToken, token)
+
+	deleteURL := fmt.Sprintf("https://open.feishu.cn/open-apis/bitable/v1/apps/%s/tables/%s/records/%s", realAppToken, tableID, recordID)
+	_, body, err := s.handleHTTPRequest("DELETE", deleteURL, token, nil)
+	if err != nil {
+		return fmt.Errorf("删除记录失败: %w", err)
+	}
+
+	type DeleteRecordResponse struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+
+	var result DeleteRecordResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	if result.Code != 0 {
+		fmt.Printf("📋 删除记录API响应: %s\n", string(body))
+		return fmt.Errorf("删除记录失败: %s (Code: %d)", result.Msg, result.Code)
+	}
+
+	return nil
+}
+
+// encodeFields 按表格字段类型编码记录的字段值，按选项策略处理单选/多选字段的新选项，
+// 将人员字段中的邮箱转换为用户ID，并将关联字段中填写的关联记录标题转换为记录ID
+// 获取表格字段失败时不做转换，字段值原样提交
+func (s *LarkService) encodeFields(appToken, tableID, token string, fields map[string]interface{}, opts RecordOptions) (map[string]interface{}, error) {
+	tableFields, err := s.GetTableFieldsWithToken(appToken, tableID, token)
+	if err != nil {
+		fmt.Printf("⚠️ 获取表格字段失败，字段值不做转换: %v\n", err)
+		return fields, nil
+	}
+
+	encoded, err := EncodeRecordFields(tableFields, fields, opts.location())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyOptionPolicies(appToken, tableID, token, tableFields, encoded, opts); err != nil {
+		return nil, err
+	}
+	if err := s.resolveUserEmails(tableFields, encoded, token); err != nil {
+		return nil, err
+	}
+	if err := s.resolveLinkRecords(appToken, token, tableFields, encoded, opts); err != nil {
+		return nil, err
+	}
+	return encoded, nil
+}
+
+// NormalizeFields 按表格字段类型编码字段值，用于记录提交日志，不会新建选项或关联记录
+// 获取字段或编码失败时返回原始字段值
+func (s *LarkService) NormalizeFields(appToken, tableID string, fields map[string]interface{}, opts RecordOptions) map[string]interface{} {
+	tableFields, err := s.GetTableFields(appToken, tableID)
+	if err != nil {
+		return fields
+	}
+	encoded, err := EncodeRecordFields(tableFields, fields, opts.location())
+	if err != nil {
+		return fields
+	}
+	return encoded
+}
+
+// resolveUserEmails 将人员字段中以邮箱填写的用户转换为user_id
+func (s *LarkService) resolveUserEmails(tableFields []models.Field, fields map[string]interface{}, token string) error {
+	var emails []string
+	for _, field := range tableFields {
+		if fieldKind(field) != "User" {
+			continue
+		}
+		users, _ := fields[field.FieldName].([]interface{})
+		for _, user := range users {
+			if m, ok := user.(map[string]interface{}); ok {
+				if id, _ := m["id"].(string); strings.Contains(id, "@") {
+					emails = append(emails, id)
+				}
+			}
+		}
+	}
+	if len(emails) == 0 {
+		return nil
+	}
+
+	userIDs, err := s.lookupUserIDsByEmail(emails, token)
+	if err != nil {
+		return err
+	}
+
+	var errs FieldValueErrors
+	for _, field := range tableFields {
+		if fieldKind(field) != "User" {
+			continue
+		}
+		users, _ := fields[field.FieldName].([]interface{})
+		for _, user := range users {
+			m, ok := user.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			id, _ := m["id"].(string)
+			if !strings.Contains(id, "@") {
+				continue
+			}
+			if userID, found := userIDs[id]; found {
+				m["id"] = userID
+			} else {
+				errs = append(errs, &FieldValueError{Field: field.FieldName, Code: FieldErrUnknownUser, Message: fmt.Sprintf("未找到邮箱为 %s 的用户", id)})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// lookupUserIDsByEmail 通过邮箱批量查询用户的user_id
+func (s *LarkService) lookupUserIDsByEmail(emails []string, token string) (map[string]string, error) {
+	reqBodyBytes, err := json.Marshal(map[string]interface{}{"emails": emails})
+	if err != nil {
+		return nil, fmt.Errorf("构建请求体失败: %w", err)
+	}
+
+	lookupURL := "https://open.feishu.cn/open-apis/contact/v3/users/batch_get_id?user_id_type=user_id"
+	_, body, err := s.handleHTTPRequest("POST", lookupURL, token, reqBodyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	type BatchGetIDResponse struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			UserList []struct {
+				Email  string `json:"email"`
+				UserID string `json:"user_id"`
+			} `json:"user_list"`
+		} `json:"data"`
+	}
+
+	var result BatchGetIDResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	if result.Code != 0 {
+		fmt.Printf("📋 查询用户API响应: %s\n", string(body))
+		return nil, fmt.Errorf("查询用户失败: %s (Code: %d)", result.Msg, result.Code)
+	}
+
+	userIDs := make(map[string]string)
+	for _, user := range result.Data.UserList {
+		if user.UserID != "" {
+			userIDs[user.Email] = user.UserID
+		}
+	}
+	return userIDs, nil
+}
+
+// buildSearchFilter 将条件组合转换为飞书查询接口的筛选条件
+func buildSearchFilter(group models.ConditionGroup) map[string]interface{} {
+	conjunction := group.Conjunction
+	if conjunction == "" {
+		conjunction = models.ConjunctionAnd
+	}
+
+	conditions := make([]searchCondition, 0, len(group.Conditions))
+	for _, cond := range group.Conditions {
+		value := cond.Value
+		if value == nil {
+			value = []string{}
+		}
+		conditions = append(conditions, searchCondition{
+			FieldName: cond.FieldName,
+			Operator:  cond.Operator,
+			Value:     value,
+		})
+	}
+
+	return map[string]interface{}{
+		"conjunction": conjunction,
+		"conditions":  conditions,
+	}
+}
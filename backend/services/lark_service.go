@@ -182,6 +182,8 @@ type LarkService struct {
 	// 数据表列表缓存
 	tablesCache     sync.Map
 	tablesCacheTime sync.Map
+	// 已订阅记录变更事件的多维表格
+	subscribedFiles sync.Map
 	// 拆分的服务
 	bitableService  *LarkBitableService
 	messageService  *LarkMessageService
@@ -246,9 +248,9 @@ func (s *LarkService) GetBitableTables(appToken string, isWiki bool) ([]models.T
 	return s.bitableService.GetBitableTables(appToken, isWiki)
 }
 
-// CreateTaskFromFieldValues 从字段值创建任务
+// CreateTaskFromFieldValues 从字段值创建任务，返回任务GUID
 // 该方法将调用taskService的同名方法
-func (s *LarkService) CreateTaskFromFieldValues(tableConfig models.TableConfig, fieldValues map[string]interface{}) error {
+func (s *LarkService) CreateTaskFromFieldValues(tableConfig models.TableConfig, fieldValues map[string]interface{}) (string, error) {
 	return s.taskService.CreateTaskFromFieldValues(tableConfig, fieldValues)
 }
 
@@ -580,64 +582,10 @@ func (s *LarkService) GetTableFieldsWithToken(appToken, tableID, token string) (
 		}
 	}
 
-	// 使用实际的 appToken 获取字段
-	fieldsURL := fmt.Sprintf("https://open.feishu.cn/open-apis/bitable/v1/apps/%s/tables/%s/fields?user_id_type=user_id", realAppToken, tableID)
-	fieldsReq, err := http.NewRequest("GET", fieldsURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("创建字段请求失败: %w", err)
-	}
-	fieldsReq.Header.Set("Authorization", "Bearer "+token)
-
-	fieldsResp, err := s.httpClient.Do(fieldsReq)
+	// 使用实际的 appToken 获取字段，读取全部分页后再缓存
+	fields, err := s.fetchTableFields(realAppToken, tableID, token)
 	if err != nil {
-		return nil, fmt.Errorf("获取字段列表失败: %w", err)
-	}
-	defer fieldsResp.Body.Close()
-
-	fieldsBody, err := io.ReadAll(fieldsResp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取字段响应失败: %w", err)
-	}
-
-	type FieldsResponse struct {
-		Code int    `json:"code"`
-		Msg  string `json:"msg"`
-		Data struct {
-			Items []struct {
-				FieldName string `json:"field_name"`
-				Type      int    `json:"type"`
-				FieldId   string `json:"field_id"`
-				Property  *struct {
-					IsPrimary *bool `json:"is_primary"`
-				} `json:"property,omitempty"`
-				UiType string `json:"ui_type"`
-			} `json:"items"`
-		} `json:"data"`
-	}
-
-	var fieldsResult FieldsResponse
-	if err := json.Unmarshal(fieldsBody, &fieldsResult); err != nil {
-		return nil, fmt.Errorf("解析字段响应失败: %w", err)
-	}
-
-	if fieldsResult.Code != 0 {
-		fmt.Printf("📋 字段API响应: %s\n", string(fieldsBody))
-		return nil, fmt.Errorf("获取字段列表失败: %s (Code: %d)", fieldsResult.Msg, fieldsResult.Code)
-	}
-
-	var fields []models.Field
-	for _, field := range fieldsResult.Data.Items {
-		isPrimary := false
-		if field.Property != nil && field.Property.IsPrimary != nil {
-			isPrimary = *field.Property.IsPrimary
-		}
-		fields = append(fields, models.Field{
-			FieldName: field.FieldName,
-			FieldType: fmt.Sprintf("%d", field.Type),
-			FieldID:   field.FieldId,
-			IsPrimary: isPrimary,
-			UiType:    field.UiType,
-		})
+		return nil, err
 	}
 
 	// 缓存字段结果
@@ -669,56 +617,87 @@ func (s *LarkService) getTableFieldsViaHTTP(appToken, tableID string) ([]models.
 	}
 
 	// 使用实际的 appToken 获取字段
-	fieldsURL := fmt.Sprintf("https://open.feishu.cn/open-apis/bitable/v1/apps/%s/tables/%s/fields?user_id_type=user_id", realAppToken, tableID)
-
-	// 使用通用HTTP请求处理函数
-	fieldsResp, fieldsBody, err := s.handleHTTPRequest("GET", fieldsURL, token, nil)
-	if err != nil {
-		return nil, fmt.Errorf("获取字段列表失败: %w", err)
-	}
-	defer fieldsResp.Body.Close()
+	return s.fetchTableFields(realAppToken, tableID, token)
+}
 
+// fetchTableFields 分页获取数据表的全部字段，字段超过一页时继续读取直到没有更多数据
+func (s *LarkService) fetchTableFields(realAppToken, tableID, token string) ([]models.Field, error) {
 	type FieldsResponse struct {
 		Code int    `json:"code"`
 		Msg  string `json:"msg"`
 		Data struct {
-			Items []struct {
+			HasMore   bool   `json:"has_more"`
+			PageToken string `json:"page_token"`
+			Items     []struct {
 				FieldName string `json:"field_name"`
 				Type      int    `json:"type"`
 				FieldId   string `json:"field_id"`
 				Property  *struct {
-					IsPrimary *bool `json:"is_primary"`
+					IsPrimary *bool   `json:"is_primary"`
+					TableID   *string `json:"table_id"`
+					Options   []struct {
+						Name string `json:"name"`
+					} `json:"options"`
 				} `json:"property,omitempty"`
 				UiType string `json:"ui_type"`
 			} `json:"items"`
 		} `json:"data"`
 	}
 
-	var fieldsResult FieldsResponse
-	if err := json.Unmarshal(fieldsBody, &fieldsResult); err != nil {
-		return nil, fmt.Errorf("解析字段响应失败: %w", err)
-	}
+	var fields []models.Field
+	pageToken := ""
+	for {
+		fieldsURL := fmt.Sprintf("https://open.feishu.cn/open-apis/bitable/v1/apps/%s/tables/%s/fields?user_id_type=user_id&page_size=100", realAppToken, tableID)
+		if pageToken != "" {
+			fieldsURL += "&page_token=" + pageToken
+		}
 
-	if fieldsResult.Code != 0 {
-		return nil, fmt.Errorf("获取字段列表失败: %s (Code: %d)", fieldsResult.Msg, fieldsResult.Code)
-	}
+		_, fieldsBody, err := s.handleHTTPRequest("GET", fieldsURL, token, nil)
+		if err != nil {
+			return nil, fmt.Errorf("获取字段列表失败: %w", err)
+		}
 
-	var fields []models.Field
-	for _, field := range fieldsResult.Data.Items {
-		isPrimary := false
-		if field.Property != nil && field.Property.IsPrimary != nil {
-			isPrimary = *field.Property.IsPrimary
+		var fieldsResult FieldsResponse
+		if err := json.Unmarshal(fieldsBody, &fieldsResult); err != nil {
+			return nil, fmt.Errorf("解析字段响应失败: %w", err)
 		}
-		fields = append(fields, models.Field{
-			FieldName: field.FieldName,
-			FieldType: fmt.Sprintf("%d", field.Type),
-			FieldID:   field.FieldId,
-			IsPrimary: isPrimary,
-			UiType:    field.UiType,
-		})
-	}
 
-	return fields, nil
+		if fieldsResult.Code != 0 {
+			fmt.Printf("📋 字段API响应: %s\n", string(fieldsBody))
+			return nil, fmt.Errorf("获取字段列表失败: %s (Code: %d)", fieldsResult.Msg, fieldsResult.Code)
+		}
+
+		for _, field := range fieldsResult.Data.Items {
+			isPrimary := false
+			linkTableID := ""
+			var options []string
+			if field.Property != nil {
+				if field.Property.IsPrimary != nil {
+					isPrimary = *field.Property.IsPrimary
+				}
+				if field.Property.TableID != nil {
+					linkTableID = *field.Property.TableID
+				}
+				for _, option := range field.Property.Options {
+					options = append(options, option.Name)
+				}
+			}
+			fields = append(fields, models.Field{
+				FieldName:   field.FieldName,
+				FieldType:   fmt.Sprintf("%d", field.Type),
+				FieldID:     field.FieldId,
+				IsPrimary:   isPrimary,
+				UiType:      field.UiType,
+				Options:     options,
+				LinkTableID: linkTableID,
+			})
+		}
+
+		if !fieldsResult.Data.HasMore || fieldsResult.Data.PageToken == "" {
+			return fields, nil
+		}
+		pageToken = fieldsResult.Data.PageToken
+	}
 }
 
 // GetTableFields 获取数据表的所有字段（带缓存）
@@ -736,7 +715,7 @@ func (s *LarkService) GetTableFields(appToken, tableID string) ([]models.Field,
 
 
 // AddRecord 新增记录
-func (s *LarkService) AddRecord(appToken, tableID string, fields map[string]interface{}) (string, error) {
+func (s *LarkService) AddRecord(appToken, tableID string, fields map[string]interface{}, opts RecordOptions) (string, error) {
 	// 获取访问令牌
 	token, err := s.GetTenantAccessToken()
 	if err != nil {
@@ -756,6 +735,17 @@ func (s *LarkService) AddRecord(appToken, tableID string, fields map[string]inte
 		realAppToken = objToken
 	}
 
+	// 按字段类型将输入转换为飞书接口需要的格式
+	fields, err = s.encodeFields(realAppToken, tableID, token, fields, opts)
+	if err != nil {
+		return "", err
+	}
+
+	return s.addEncodedRecord(realAppToken, tableID, token, fields)
+}
+
+// addEncodedRecord 写入已按字段类型编码的记录，realAppToken需为解析后的多维表格Token
+func (s *LarkService) addEncodedRecord(realAppToken, tableID, token string, fields map[string]interface{}) (string, error) {
 	// 首先尝试使用SDK添加记录
 	record := larkbitable.NewAppTableRecordBuilder().
 		Fields(fields).
@@ -785,122 +775,6 @@ func (s *LarkService) AddRecord(appToken, tableID string, fields map[string]inte
 	fmt.Printf("📋 准备添加记录 - AppToken: %s, TableID: %s\n", realAppToken, tableID)
 	fmt.Printf("📋 Fields数据: %+v\n", fields)
 
-	// 获取表格字段信息，用于验证
-	fmt.Println("🔍 获取表格字段信息，用于验证...")
-	tableFields, err := s.GetTableFieldsWithToken(realAppToken, tableID, token)
-	if err != nil {
-		fmt.Printf("⚠️ 获取表格字段失败: %v\n", err)
-	} else {
-		fmt.Printf("✅ 表格字段信息: %d 个字段\n", len(tableFields))
-		for _, field := range tableFields {
-			fmt.Printf("  - 字段名: %s, 类型: %s, ID: %s\n", field.FieldName, field.FieldType, field.FieldID)
-		}
-
-		// 检查必填字段是否都已提供
-		fmt.Println("🔍 检查必填字段是否都已提供...")
-		for _, field := range tableFields {
-			// 检查是否为必填字段（通常字段ID以 "opt" 开头的是可选字段，其他可能是必填）
-			isRequired := !strings.HasPrefix(field.FieldID, "opt")
-			if isRequired {
-				if _, exists := fields[field.FieldName]; !exists {
-					fmt.Printf("⚠️ 必填字段缺失: %s (ID: %s)\n", field.FieldName, field.FieldID)
-				} else {
-					fmt.Printf("✅ 必填字段已提供: %s\n", field.FieldName)
-				}
-			}
-		}
-
-		// 检查字段类型是否匹配并格式化字段值
-		fmt.Println("🔍 检查字段类型是否匹配并格式化字段值...")
-		for fieldName, fieldValue := range fields {
-			// 查找对应的字段定义
-			var fieldDef *models.Field
-			for _, field := range tableFields {
-				if field.FieldName == fieldName {
-					fieldDef = &field
-					break
-				}
-			}
-
-			if fieldDef != nil {
-				// 格式化字段值，特别是User类型字段
-				if fieldValue != nil && fieldValue != "" {
-					// 处理User类型字段（ui_type为User或field_type为11）
-					if (fieldDef.UiType == "User" || fieldDef.FieldType == "11") && !strings.Contains(fmt.Sprintf("%T", fieldValue), "[]") {
-						// 将普通字符串转换为User类型需要的格式: [{"id": "用户ID"}]
-						userId := fmt.Sprintf("%v", fieldValue)
-						fields[fieldName] = []interface{}{map[string]interface{}{"id": userId}}
-						fmt.Printf("✅ User类型字段 '%s' 的值已格式化: %+v\n", fieldName, fields[fieldName])
-					}
-				}
-
-				// 根据字段类型检查值
-				switch fieldDef.FieldType {
-				case "1": // 文本
-					if fieldValue != nil && fmt.Sprintf("%v", fieldValue) == "" {
-						fmt.Printf("⚠️ 文本字段 '%s' 的值为空\n", fieldName)
-					}
-				case "2": // 数字
-					if _, ok := fieldValue.(float64); !ok && fieldValue != nil {
-						fmt.Printf("⚠️ 数字字段 '%s' 的值类型不匹配，期望数字，实际: %T\n", fieldName, fieldValue)
-					}
-				case "3": // 单选
-					if fieldValue == nil || fmt.Sprintf("%v", fieldValue) == "" {
-						fmt.Printf("⚠️ 单选字段 '%s' 的值为空\n", fieldName)
-					}
-				case "4": // 多选
-					if _, ok := fieldValue.([]interface{}); !ok && fieldValue != nil {
-						fmt.Printf("⚠️ 多选字段 '%s' 的值类型不匹配，期望数组，实际: %T\n", fieldName, fieldValue)
-					}
-				case "5": // 日期
-					if _, ok := fieldValue.(int64); !ok && fieldValue != nil {
-						fmt.Printf("⚠️ 日期字段 '%s' 的值类型不匹配，期望时间戳，实际: %T\n", fieldName, fieldValue)
-					}
-				case "11": // 人员
-					if _, ok := fieldValue.([]interface{}); !ok && fieldValue != nil {
-						fmt.Printf("⚠️ 人员字段 '%s' 的值类型不匹配，期望数组，实际: %T\n", fieldName, fieldValue)
-					}
-				case "13": // 附件
-					if _, ok := fieldValue.([]interface{}); !ok && fieldValue != nil {
-						fmt.Printf("⚠️ 附件字段 '%s' 的值类型不匹配，期望数组，实际: %T\n", fieldName, fieldValue)
-					}
-				case "15": // 复选框
-					if _, ok := fieldValue.(bool); !ok && fieldValue != nil {
-						fmt.Printf("⚠️ 复选框字段 '%s' 的值类型不匹配，期望布尔值，实际: %T\n", fieldName, fieldValue)
-					}
-				case "17": // URL
-					if fieldValue != nil && fmt.Sprintf("%v", fieldValue) == "" {
-						fmt.Printf("⚠️ URL字段 '%s' 的值为空\n", fieldName)
-					}
-				case "18": // 邮箱
-					if fieldValue != nil && fmt.Sprintf("%v", fieldValue) == "" {
-						fmt.Printf("⚠️ 邮箱字段 '%s' 的值为空\n", fieldName)
-					}
-				case "19": // 电话
-					if fieldValue != nil && fmt.Sprintf("%v", fieldValue) == "" {
-						fmt.Printf("⚠️ 电话字段 '%s' 的值为空\n", fieldName)
-					}
-				case "20": // 进度
-					if _, ok := fieldValue.(float64); !ok && fieldValue != nil {
-						fmt.Printf("⚠️ 进度字段 '%s' 的值类型不匹配，期望数字，实际: %T\n", fieldName, fieldValue)
-					}
-				case "21": // 评分
-					if _, ok := fieldValue.(float64); !ok && fieldValue != nil {
-						fmt.Printf("⚠️ 评分字段 '%s' 的值类型不匹配，期望数字，实际: %T\n", fieldName, fieldValue)
-					}
-				case "23": // 货币
-					if _, ok := fieldValue.(float64); !ok && fieldValue != nil {
-						fmt.Printf("⚠️ 货币字段 '%s' 的值类型不匹配，期望数字，实际: %T\n", fieldName, fieldValue)
-					}
-				default:
-					fmt.Printf("⚠️ 未知字段类型 '%s' 的字段 '%s'\n", fieldDef.FieldType, fieldName)
-				}
-			} else {
-				fmt.Printf("⚠️ 未找到字段 '%s' 的定义\n", fieldName)
-			}
-		}
-	}
-
 	// 确保fields不为空
 	if fields == nil {
 		fields = make(map[string]interface{})
@@ -936,6 +810,10 @@ func (s *LarkService) AddRecord(appToken, tableID string, fields map[string]inte
 	if err != nil {
 		return "", fmt.Errorf("读取响应失败: %w", err)
 	}
+	if err := checkLarkAvailable(httpResp); err != nil {
+		fmt.Printf("📋 添加记录API响应: %s\n", string(httpBody))
+		return "", fmt.Errorf("添加记录失败: %w", err)
+	}
 
 	type AddRecordResponse struct {
 		Code int    `json:"code"`
@@ -991,10 +869,26 @@ func (s *LarkService) AddRecord(appToken, tableID string, fields map[string]inte
 // CheckFieldsCompleted 检查记录中的指定字段是否已完成，并返回字段值
 // 优化：使用统一的Wiki Token处理函数，改进错误处理
 func (s *LarkService) CheckFieldsCompleted(appToken, tableID, recordID string, checkFields []string) (bool, map[string]interface{}, error) {
+	return s.CheckRecordCompleted(appToken, tableID, recordID, DefaultCompletionRule(checkFields))
+}
+
+// CheckRecordCompleted 检查记录是否满足完成条件，并返回条件涉及字段中已有值的字段
+func (s *LarkService) CheckRecordCompleted(appToken, tableID, recordID string, rule models.ConditionGroup) (bool, map[string]interface{}, error) {
+	fields, err := s.getRecordFieldsViaHTTP(appToken, tableID, recordID)
+	if err != nil {
+		return false, nil, err
+	}
+
+	completed, fieldValues := EvaluateCompletion(rule, fields)
+	return completed, fieldValues, nil
+}
+
+// getRecordFieldsViaHTTP 通过HTTP API获取记录的所有字段
+func (s *LarkService) getRecordFieldsViaHTTP(appToken, tableID, recordID string) (map[string]interface{}, error) {
 	// 直接使用HTTP API获取记录，确保指定user_id_type=user_id
 	token, err := s.GetTenantAccessToken()
 	if err != nil {
-		return false, nil, fmt.Errorf("获取访问令牌失败: %w", err)
+		return nil, fmt.Errorf("获取访问令牌失败: %w", err)
 	}
 
 	// 检查 appToken 是否是 wiki token，如果是需要先获取 obj_token
@@ -1016,20 +910,20 @@ func (s *LarkService) CheckFieldsCompleted(appToken, tableID, recordID string, c
 
 	httpReq, err := http.NewRequest("GET", recordURL, nil)
 	if err != nil {
-		return false, nil, fmt.Errorf("创建请求失败: %w", err)
+		return nil, fmt.Errorf("创建请求失败: %w", err)
 	}
 	httpReq.Header.Set("Authorization", "Bearer "+token)
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	httpResp, err := s.httpClient.Do(httpReq)
 	if err != nil {
-		return false, nil, fmt.Errorf("获取记录失败: %w", err)
+		return nil, fmt.Errorf("获取记录失败: %w", err)
 	}
 	defer httpResp.Body.Close()
 
 	httpBody, err := io.ReadAll(httpResp.Body)
 	if err != nil {
-		return false, nil, fmt.Errorf("读取响应失败: %w", err)
+		return nil, fmt.Errorf("读取响应失败: %w", err)
 	}
 
 	type GetRecordResponse struct {
@@ -1044,28 +938,15 @@ func (s *LarkService) CheckFieldsCompleted(appToken, tableID, recordID string, c
 
 	var getResult GetRecordResponse
 	if err := json.Unmarshal(httpBody, &getResult); err != nil {
-		return false, nil, fmt.Errorf("解析响应失败: %w", err)
+		return nil, fmt.Errorf("解析响应失败: %w", err)
 	}
 
 	if getResult.Code != 0 {
 		fmt.Printf("📋 获取记录API响应: %s\n", string(httpBody))
-		return false, nil, fmt.Errorf("获取记录失败: %s (Code: %d)", getResult.Msg, getResult.Code)
-	}
-
-	// 检查字段是否都已完成，并收集字段值
-	fieldValues := make(map[string]interface{})
-	allCompleted := true
-
-	for _, fieldName := range checkFields {
-		value := getResult.Data.Record.Fields[fieldName]
-		if value == nil || value == "" {
-			allCompleted = false
-			break
-		}
-		fieldValues[fieldName] = value
+		return nil, fmt.Errorf("获取记录失败: %s (Code: %d)", getResult.Msg, getResult.Code)
 	}
 
-	return allCompleted, fieldValues, nil
+	return getResult.Data.Record.Fields, nil
 }
 
 // getWikiTokenInfo 获取Wiki Token的实际AppToken信息
@@ -1109,6 +990,20 @@ func (s *LarkService) getWikiTokenInfo(appToken, token string) (isWiki bool, obj
 
 
 
+// resolveAppToken 将Wiki Token解析为实际的多维表格AppToken
+func (s *LarkService) resolveAppToken(appToken, token string) string {
+	isWiki, objType, objToken, wikiErr := s.getWikiTokenInfo(appToken, token)
+	if wikiErr != nil {
+		fmt.Printf("⚠️ Wiki Token处理警告: %v\n", wikiErr)
+	}
+
+	if isWiki && objType == "bitable" && objToken != "" {
+		fmt.Printf("✅ 检测到 Wiki Token，获取到 ObjToken: %s\n", objToken)
+		return objToken
+	}
+	return appToken
+}
+
 // GetRecord 获取记录的所有字段
 // 优化：使用统一的Wiki Token处理函数，改进错误处理
 func (s *LarkService) GetRecord(appToken, tableID, recordID string) (map[string]interface{}, error) {
@@ -1178,12 +1073,22 @@ func (s *LarkService) GetRecord(appToken, tableID, recordID string) (map[string]
 	return getResult.Data.Record.Fields, nil
 }
 
-// SendMessage 发送消息到指定群聊
-func (s *LarkService) SendMessage(groupChatID, message string) error {
+// SendMessage 发送消息到指定群聊，返回消息ID
+func (s *LarkService) SendMessage(groupChatID, message string) (string, error) {
 	return s.messageService.SendMessage(groupChatID, message)
 }
 
-// CreateTask 创建任务
-func (s *LarkService) CreateTask(title string, dueTimestamp int64, isAllDay bool, assignees []map[string]interface{}) error {
+// RecallMessage 撤回机器人发送的消息
+func (s *LarkService) RecallMessage(messageID string) error {
+	return s.messageService.RecallMessage(messageID)
+}
+
+// CreateTask 创建任务，返回任务GUID
+func (s *LarkService) CreateTask(title string, dueTimestamp int64, isAllDay bool, assignees []map[string]interface{}) (string, error) {
 	return s.taskService.CreateTask(title, dueTimestamp, isAllDay, assignees)
+}
+
+// DeleteTask 删除任务
+func (s *LarkService) DeleteTask(taskGUID string) error {
+	return s.taskService.DeleteTask(taskGUID)
 }
\ No newline at end of file
@@ -27,11 +27,11 @@ func (s *LarkTaskService) getTenantAccessToken() (string, error) {
 	return s.GetTenantAccessToken()
 }
 
-// CreateTask 创建一个飞书任务
-func (s *LarkTaskService) CreateTask(title string, dueTimestamp int64, isAllDay bool, assignees []map[string]interface{}) error {
+// CreateTask 创建一个飞书任务，返回任务GUID
+func (s *LarkTaskService) CreateTask(title string, dueTimestamp int64, isAllDay bool, assignees []map[string]interface{}) (string, error) {
 	token, err := s.getTenantAccessToken()
 	if err != nil {
-		return fmt.Errorf("获取访问令牌失败: %w", err)
+		return "", fmt.Errorf("获取访问令牌失败: %w", err)
 	}
 
 	// 构建成员列表
@@ -48,7 +48,7 @@ func (s *LarkTaskService) CreateTask(title string, dueTimestamp int64, isAllDay
 	}
 
 	if len(members) == 0 {
-		return fmt.Errorf("没有有效的负责人ID")
+		return "", fmt.Errorf("没有有效的负责人ID")
 	}
 
 	// 构建请求体，使用用户提供的API格式
@@ -63,7 +63,7 @@ func (s *LarkTaskService) CreateTask(title string, dueTimestamp int64, isAllDay
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return fmt.Errorf("请求体序列化失败: %w", err)
+		return "", fmt.Errorf("请求体序列化失败: %w", err)
 	}
 
 	// 使用BaseService的handleHTTPRequest方法发送请求
@@ -74,7 +74,7 @@ func (s *LarkTaskService) CreateTask(title string, dueTimestamp int64, isAllDay
 		jsonData,
 	)
 	if err != nil {
-		return fmt.Errorf("创建任务失败: %w", err)
+		return "", fmt.Errorf("创建任务失败: %w", err)
 	}
 
 	// 解析响应
@@ -92,23 +92,59 @@ func (s *LarkTaskService) CreateTask(title string, dueTimestamp int64, isAllDay
 
 	var result CreateTaskResponse
 	if err := json.Unmarshal(body, &result); err != nil {
-		return fmt.Errorf("解析响应失败: %w", err)
+		return "", fmt.Errorf("解析响应失败: %w", err)
 	}
 
 	if result.Code != 0 {
 		log.Printf("📋 创建任务API响应: %s", string(body))
-		return fmt.Errorf("创建任务失败: %s (Code: %d)", result.Msg, result.Code)
+		return "", fmt.Errorf("创建任务失败: %s (Code: %d)", result.Msg, result.Code)
 	}
 
 	// 输出创建成功的信息
 	log.Printf("✅ 任务创建成功! 任务ID: %s, 任务GUID: %s", result.Data.Task.TaskID, result.Data.Task.GUID)
 	log.Printf("🔗 任务链接: %s", result.Data.Task.URL)
 
+	return result.Data.Task.GUID, nil
+}
+
+// DeleteTask 删除任务
+func (s *LarkTaskService) DeleteTask(taskGUID string) error {
+	token, err := s.getTenantAccessToken()
+	if err != nil {
+		return fmt.Errorf("获取访问令牌失败: %w", err)
+	}
+
+	_, body, err := s.handleHTTPRequest(
+		"DELETE",
+		"https://open.feishu.cn/open-apis/task/v2/tasks/"+taskGUID,
+		token,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("删除任务失败: %w", err)
+	}
+
+	type DeleteTaskResponse struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+
+	var result DeleteTaskResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	if result.Code != 0 {
+		log.Printf("📋 删除任务API响应: %s", string(body))
+		return fmt.Errorf("删除任务失败: %s (Code: %d)", result.Msg, result.Code)
+	}
+
+	log.Printf("✅ 任务 %s 已删除", taskGUID)
 	return nil
 }
 
-// CreateTaskFromFieldValues 从字段值创建任务
-func (s *LarkTaskService) CreateTaskFromFieldValues(tableConfig models.TableConfig, fieldValues map[string]interface{}) error {
+// CreateTaskFromFieldValues 从字段值创建任务，返回任务GUID，未启用任务创建时返回空字符串
+func (s *LarkTaskService) CreateTaskFromFieldValues(tableConfig models.TableConfig, fieldValues map[string]interface{}) (string, error) {
 	// 获取任务配置
 	taskConfig := tableConfig.Task
 
@@ -116,7 +152,7 @@ func (s *LarkTaskService) CreateTaskFromFieldValues(tableConfig models.TableConf
 	if !taskConfig.Enabled {
 		// 检查旧版本配置兼容性
 		if !tableConfig.CreateTask {
-			return nil
+			return "", nil
 		}
 		// 使用旧版本配置
 		return s.createTaskFromOldConfig(tableConfig, fieldValues)
@@ -125,7 +161,7 @@ func (s *LarkTaskService) CreateTaskFromFieldValues(tableConfig models.TableConf
 	// 提取任务信息
 	taskTitle, dueTimestamp, isAllDay, assignees, err := s.extractTaskInfo(taskConfig, fieldValues)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// 创建任务
@@ -133,7 +169,7 @@ func (s *LarkTaskService) CreateTaskFromFieldValues(tableConfig models.TableConf
 }
 
 // createTaskFromOldConfig 从旧版本配置创建任务（向后兼容）
-func (s *LarkTaskService) createTaskFromOldConfig(tableConfig models.TableConfig, fieldValues map[string]interface{}) error {
+func (s *LarkTaskService) createTaskFromOldConfig(tableConfig models.TableConfig, fieldValues map[string]interface{}) (string, error) {
 	// 构建临时任务配置
 	taskConfig := models.TaskConfig{
 		Enabled:        true,
@@ -147,7 +183,7 @@ func (s *LarkTaskService) createTaskFromOldConfig(tableConfig models.TableConfig
 	// 提取任务信息
 	taskTitle, dueTimestamp, isAllDay, assignees, err := s.extractTaskInfo(taskConfig, fieldValues)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// 创建任务
@@ -0,0 +1,504 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"lark-record/models"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 待提交队列的重试配置
+const (
+	DefaultOutboxBaseDelay   = 30 * time.Second // 首次重试延迟
+	DefaultOutboxMaxDelay    = 30 * time.Minute // 最大重试间隔
+	DefaultOutboxMaxAttempts = 20               // 最大自动重试次数，超过后需要手动重试
+	outboxBackoffFactor      = 2.0              // 重试间隔退避系数
+	outboxWorkerTick         = 5 * time.Second  // 后台提交扫描间隔
+)
+
+var (
+	// ErrOutboxNotFound 待提交队列中没有该记录
+	ErrOutboxNotFound = errors.New("待提交记录不存在")
+	// ErrOutboxBusy 该记录正在提交中
+	ErrOutboxBusy = errors.New("该记录正在提交中，请稍后再试")
+	// ErrDuplicateRecord 数据表中已存在唯一键相同的记录
+	ErrDuplicateRecord = errors.New("已存在相同的记录")
+	// ErrLarkUnavailable 飞书接口返回5xx或429，服务暂时不可用
+	ErrLarkUnavailable = errors.New("飞书服务暂时不可用")
+	// ErrWriteUncertain 新增记录的请求已发出但没有收到飞书的响应，记录可能已经写入
+	ErrWriteUncertain = errors.New("无法确定记录是否已写入，请确认后再重试")
+)
+
+// retryableLarkCodes 飞书限流和服务端临时错误的错误码，稍后重试可能成功
+var retryableLarkCodes = map[int]bool{
+	99991400: true, // 应用请求频率超限
+	1254290:  true, // 请求过于频繁
+	1254291:  true, // 写入冲突
+	1254607:  true, // 数据未就绪
+	1255001:  true, // 飞书内部错误
+	1255002:  true, // 飞书内部错误
+	1255040:  true, // 请求超时
+}
+
+// IsRetryableWriteError 判断写入错误是否是暂时性的：网络错误、超时、飞书服务端错误和限流
+// 只有这类错误会加入待提交队列重试，字段不存在、无权限、数据表不存在等其他飞书错误直接返回给客户端；
+// 新增记录的错误需先经过UncertainWriteError，可能已经写入的记录不会重试
+func IsRetryableWriteError(err error) bool {
+	if err == nil || errors.Is(err, ErrWriteUncertain) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrLarkUnavailable) {
+		return true
+	}
+	return retryableLarkCodes[LarkErrorCode(err)]
+}
+
+// UncertainWriteError 新增记录的请求已发出但没有收到响应（超时、连接中断）时返回ErrWriteUncertain，其他错误原样返回
+// 连接未建立（DNS解析、连接失败）时请求没有发出，记录一定未写入，仍可以重试
+func UncertainWriteError(err error) error {
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	if errors.As(err, &dnsErr) || (errors.As(err, &opErr) && opErr.Op == "dial") {
+		return err
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %v", ErrWriteUncertain, err)
+	}
+	return err
+}
+
+// checkLarkAvailable 飞书接口返回5xx或429时返回ErrLarkUnavailable
+func checkLarkAvailable(resp *http.Response) error {
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("%w: HTTP %d", ErrLarkUnavailable, resp.StatusCode)
+	}
+	return nil
+}
+
+// OutboxService 待提交队列
+// 飞书暂时无法访问或客户端要求延后提交的记录保存在本地文件中，由后台按退避间隔重试，
+// 提交成功后为记录创建字段检测任务
+type OutboxService struct {
+	mu             sync.Mutex
+	path           string
+	items          map[string]*models.OutboxItem
+	running        map[string]bool // 正在提交中的队列项
+	configService  *ConfigService
+	serviceManager *ServiceManager
+	watchService   *WatchService
+	history        *HistoryService
+	wake           chan struct{}
+}
+
+// NewOutboxService 创建待提交队列服务
+func NewOutboxService(path string, configService *ConfigService, serviceManager *ServiceManager, watchService *WatchService) *OutboxService {
+	if path == "" {
+		path = "./outbox.json"
+	}
+	return &OutboxService{
+		path:           path,
+		items:          make(map[string]*models.OutboxItem),
+		running:        make(map[string]bool),
+		configService:  configService,
+		serviceManager: serviceManager,
+		watchService:   watchService,
+		wake:           make(chan struct{}, 1),
+	}
+}
+
+// SetHistoryService 设置提交记录服务，后台提交的结果会更新到加入队列时的提交记录中
+func (s *OutboxService) SetHistoryService(history *HistoryService) {
+	s.history = history
+}
+
+// updateHistory 更新队列项对应的提交记录，未设置提交记录服务时不做处理
+func (s *OutboxService) updateHistory(id string, update func(*models.Submission)) {
+	if s.history != nil {
+		s.history.UpdateByOutboxID(id, update)
+	}
+}
+
+// Start 加载已持久化的待提交记录并启动后台提交
+func (s *OutboxService) Start() {
+	var items []*models.OutboxItem
+	if _, err := readJSONFile(s.path, &items); err != nil {
+		logError("加载待提交记录失败: %v", err)
+	}
+
+	s.mu.Lock()
+	for _, item := range items {
+		s.items[item.ID] = item
+	}
+	s.mu.Unlock()
+
+	if len(items) > 0 {
+		logInfo("📮 恢复了 %d 条待提交的记录", len(items))
+	}
+
+	go s.run()
+}
+
+// Enqueue 将记录加入待提交队列
+// uploads中的附件以base64格式合并到附件字段中，后台提交时重新上传
+func (s *OutboxService) Enqueue(appToken, tableID string, fields map[string]interface{}, uploads map[string][]AttachmentUpload, reason error) (models.OutboxItem, error) {
+	id, err := newRandomID()
+	if err != nil {
+		return models.OutboxItem{}, fmt.Errorf("生成队列项ID失败: %w", err)
+	}
+
+	queued := make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		queued[name] = value
+	}
+	for name, files := range uploads {
+		var list []interface{}
+		switch existing := queued[name].(type) {
+		case nil:
+		case []interface{}:
+			list = existing
+		default:
+			list = []interface{}{existing}
+		}
+		for _, file := range files {
+			list = append(list, map[string]interface{}{
+				"name":    file.FileName,
+				"content": base64.StdEncoding.EncodeToString(file.Data),
+			})
+		}
+		queued[name] = list
+	}
+
+	now := time.Now()
+	item := &models.OutboxItem{
+		ID:            id,
+		AppToken:      appToken,
+		TableID:       tableID,
+		Fields:        queued,
+		Status:        models.OutboxPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	if reason != nil {
+		// 因写入失败加入队列的记录等待一个重试间隔后再提交
+		item.LastError = reason.Error()
+		item.NextAttemptAt = now.Add(DefaultOutboxBaseDelay)
+	}
+
+	s.mu.Lock()
+	s.items[id] = item
+	s.persistLocked()
+	s.mu.Unlock()
+
+	logInfo("📮 记录已加入待提交队列: %s (表格: %s)", id, tableID)
+	s.wakeWorker()
+	return *item, nil
+}
+
+// List 获取所有待提交的记录，按加入队列的时间排序
+func (s *OutboxService) List() []models.OutboxItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := make([]models.OutboxItem, 0, len(s.items))
+	for _, item := range s.items {
+		items = append(items, *item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.Before(items[j].CreatedAt)
+	})
+	return items
+}
+
+// Retry 立即提交指定的记录，包括已停止自动重试的记录，返回新记录的ID
+func (s *OutboxService) Retry(id string) (string, error) {
+	s.mu.Lock()
+	item, ok := s.items[id]
+	if !ok {
+		s.mu.Unlock()
+		return "", ErrOutboxNotFound
+	}
+	if s.running[id] {
+		s.mu.Unlock()
+		return "", ErrOutboxBusy
+	}
+	s.running[id] = true
+	snapshot := *item
+	s.mu.Unlock()
+
+	logInfo("📮 手动重试提交记录: %s", id)
+	return s.deliver(snapshot)
+}
+
+// Discard 从待提交队列中删除记录
+func (s *OutboxService) Discard(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.items[id]; !ok {
+		return ErrOutboxNotFound
+	}
+	if s.running[id] {
+		return ErrOutboxBusy
+	}
+	delete(s.items, id)
+	s.persistLocked()
+
+	logInfo("🗑️ 已丢弃待提交记录: %s", id)
+	s.updateHistory(id, func(entry *models.Submission) {
+		entry.Status = models.SubmissionFailed
+		entry.Error = "已从待提交队列中丢弃"
+	})
+	return nil
+}
+
+// wakeWorker 唤醒后台提交，无需等待下一次扫描
+func (s *OutboxService) wakeWorker() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run 后台提交循环，依次提交到期的记录
+func (s *OutboxService) run() {
+	ticker := time.NewTicker(outboxWorkerTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		}
+		for _, item := range s.takeDue() {
+			s.deliver(item)
+		}
+	}
+}
+
+// takeDue 取出所有到期的待提交记录并标记为提交中
+func (s *OutboxService) takeDue() []models.OutboxItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var due []models.OutboxItem
+	for id, item := range s.items {
+		if item.Status != models.OutboxPending || s.running[id] || item.NextAttemptAt.After(now) {
+			continue
+		}
+		s.running[id] = true
+		due = append(due, *item)
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].CreatedAt.Before(due[j].CreatedAt)
+	})
+	return due
+}
+
+// deliver 上传附件并写入记录，成功后从队列中删除并创建检测任务
+// 每次提交前按数据表配置重新严格校验并查找唯一键相同的记录，与直接提交的处理一致
+func (s *OutboxService) deliver(item models.OutboxItem) (string, error) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, item.ID)
+		s.mu.Unlock()
+	}()
+
+	config := s.configService.GetConfig()
+	larkService := s.serviceManager.GetLarkService(config.AppID, config.AppSecret)
+	if larkService == nil {
+		err := fmt.Errorf("飞书应用信息未配置")
+		s.markFailed(item.ID, nil, err)
+		return "", err
+	}
+
+	fields := make(map[string]interface{}, len(item.Fields))
+	for name, value := range item.Fields {
+		fields[name] = value
+	}
+	if err := larkService.PrepareAttachments(item.AppToken, item.TableID, fields, nil); err != nil {
+		s.markFailed(item.ID, nil, err)
+		return "", err
+	}
+
+	// 以下失败时附件已上传，保存file_token避免下次重试重复上传
+	opts := s.configService.RecordOptions()
+	table, hasTable := findTableConfig(config, item.AppToken, item.TableID)
+	if hasTable {
+		opts.WriteFields = table.WriteFields
+		opts.Strict = table.Strict
+	}
+
+	if hasTable && table.Strict {
+		var required []string
+		for _, field := range table.WriteFields {
+			if field.Required {
+				required = append(required, field.FieldName)
+			}
+		}
+		fieldErrs, err := larkService.ValidateRecord(item.AppToken, item.TableID, fields, required, opts)
+		if err == nil && len(fieldErrs) > 0 {
+			err = fieldErrs
+		}
+		if err != nil {
+			s.markFailed(item.ID, fields, err)
+			return "", err
+		}
+	}
+
+	if hasTable && len(table.UniqueKey.Fields) > 0 {
+		existingID, err := larkService.FindRecordByKey(item.AppToken, item.TableID, table.UniqueKey.Fields, fields)
+		if err != nil {
+			// 无法确认是否重复时不新增记录，暂时性错误稍后重试
+			s.markFailed(item.ID, fields, err)
+			return "", err
+		}
+
+		if existingID != "" {
+			switch table.UniqueKey.OnConflict {
+			case models.ConflictCreate:
+				logInfo("⚠️ 已存在相同唯一键的记录 %s，按配置仍然新增记录", existingID)
+			case models.ConflictUpdate:
+				if _, err := larkService.UpdateRecord(item.AppToken, item.TableID, existingID, fields, opts); err != nil {
+					s.markFailed(item.ID, fields, err)
+					return "", err
+				}
+				s.markDelivered(item, larkService, existingID, models.SubmissionUpdated, fields, opts)
+				return existingID, nil
+			default:
+				err := fmt.Errorf("%w（%v 相同），记录ID: %s", ErrDuplicateRecord, table.UniqueKey.Fields, existingID)
+				s.markFailed(item.ID, fields, err)
+				return "", err
+			}
+		}
+	}
+
+	recordID, err := larkService.AddRecord(item.AppToken, item.TableID, fields, opts)
+	if err != nil {
+		// 记录可能已经写入时停止自动重试，避免重复新增
+		err = UncertainWriteError(err)
+		s.markFailed(item.ID, fields, err)
+		return "", err
+	}
+	s.markDelivered(item, larkService, recordID, models.SubmissionCreated, fields, opts)
+	return recordID, nil
+}
+
+// markDelivered 将已写入飞书的记录从队列中删除，为记录创建或重新开始检测任务并更新提交记录
+// status为新增（created）或更新唯一键相同的已存在记录（updated）
+func (s *OutboxService) markDelivered(item models.OutboxItem, larkService *LarkService, recordID, status string, fields map[string]interface{}, opts RecordOptions) {
+	s.mu.Lock()
+	delete(s.items, item.ID)
+	s.persistLocked()
+	s.mu.Unlock()
+
+	logInfo("✅ 待提交记录 %s 已写入飞书，记录ID: %s", item.ID, recordID)
+	watching := false
+	if s.watchService != nil {
+		// 已在检测中的记录立即重新检测，未检测的记录创建检测任务
+		if status == models.SubmissionUpdated && s.watchService.HandleRecordChanged(item.AppToken, item.TableID, []string{recordID}) > 0 {
+			watching = true
+		} else {
+			watching = s.watchService.AddWatch(item.AppToken, item.TableID, recordID) != nil
+		}
+	}
+	s.updateHistory(item.ID, func(entry *models.Submission) {
+		entry.Status = status
+		entry.RecordID = recordID
+		entry.LarkCode = 0
+		entry.Error = ""
+		entry.Fields = historyFields(larkService.NormalizeFields(item.AppToken, item.TableID, fields, opts))
+		if watching {
+			entry.WatchOutcome = models.WatchOutcomeWatching
+		}
+	})
+}
+
+// markFailed 记录提交失败并安排下次重试，提交内容无效或达到最大重试次数时停止自动重试
+func (s *OutboxService) markFailed(id string, fields map[string]interface{}, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[id]
+	if !ok {
+		return
+	}
+	defer func() {
+		stopped := item.Status == models.OutboxFailed
+		var fieldErrs FieldValueErrors
+		rejected := errors.As(err, &fieldErrs) || errors.Is(err, ErrDuplicateRecord)
+		s.updateHistory(id, func(entry *models.Submission) {
+			switch {
+			case stopped && rejected:
+				entry.Status = models.SubmissionRejected
+			case stopped:
+				entry.Status = models.SubmissionFailed
+			}
+			entry.LarkCode = LarkErrorCode(err)
+			entry.Error = err.Error()
+		})
+	}()
+	if fields != nil {
+		item.Fields = fields
+	}
+	item.Attempts++
+	item.LastError = err.Error()
+
+	switch {
+	case !IsRetryableWriteError(err):
+		item.Status = models.OutboxFailed
+		logError("❌ 待提交记录 %s 被拒绝，停止自动重试: %v", id, err)
+	case item.Attempts >= DefaultOutboxMaxAttempts:
+		item.Status = models.OutboxFailed
+		logError("❌ 待提交记录 %s 已重试 %d 次仍未成功，停止自动重试: %v", id, item.Attempts, err)
+	default:
+		item.Status = models.OutboxPending
+		item.NextAttemptAt = time.Now().Add(outboxDelay(item.Attempts))
+		logError("⚠️ 待提交记录 %s 第 %d 次提交失败，将于 %s 重试: %v", id, item.Attempts, item.NextAttemptAt.Format("15:04:05"), err)
+	}
+	s.persistLocked()
+}
+
+// persistLocked 将当前待提交记录写入文件，调用方需持有锁
+func (s *OutboxService) persistLocked() {
+	items := make([]*models.OutboxItem, 0, len(s.items))
+	for _, item := range s.items {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.Before(items[j].CreatedAt)
+	})
+
+	if err := writeJSONFile(s.path, items); err != nil {
+		logError("保存待提交记录失败: %v", err)
+	}
+}
+
+// outboxDelay 计算第N次失败后的重试间隔：首次重试延迟 * 退避系数^(N-1)，最大不超过最大重试间隔
+func outboxDelay(attempts int) time.Duration {
+	delay := float64(DefaultOutboxBaseDelay) * math.Pow(outboxBackoffFactor, float64(attempts-1))
+	if delay > float64(DefaultOutboxMaxDelay) {
+		return DefaultOutboxMaxDelay
+	}
+	return time.Duration(delay)
+}
+
+// newRandomID 生成随机的ID，用于待提交队列项和提交记录
+func newRandomID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
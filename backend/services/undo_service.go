@@ -0,0 +1,182 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"lark-record/models"
+	"sync"
+	"time"
+)
+
+// DefaultUndoWindow 提交后默认允许撤销的时间
+const DefaultUndoWindow = 10 * time.Minute
+
+var (
+	// ErrUndoNotFound 没有对应的提交记录
+	ErrUndoNotFound = errors.New("提交记录不存在")
+	// ErrUndoNotAllowed 提交记录的状态不支持撤销
+	ErrUndoNotAllowed = errors.New("只能撤销新增成功或待提交的记录")
+	// ErrUndoAlreadyDone 提交已撤销
+	ErrUndoAlreadyDone = errors.New("该提交已撤销")
+	// ErrUndoExpired 已超过撤销时限
+	ErrUndoExpired = errors.New("已超过撤销时限")
+)
+
+// UndoService 撤销误提交的记录
+// 删除新增的记录、取消字段检测，并撤回已发送的通知消息、删除已创建的任务；待提交的记录直接从队列中删除
+type UndoService struct {
+	mu             sync.Mutex // 同一时间只执行一个撤销，避免重复撤销
+	configService  *ConfigService
+	serviceManager *ServiceManager
+	watchService   *WatchService
+	outboxService  *OutboxService
+	history        *HistoryService
+	idempotency    *IdempotencyService
+}
+
+// NewUndoService 创建撤销服务
+func NewUndoService(configService *ConfigService, serviceManager *ServiceManager, watchService *WatchService, outboxService *OutboxService, history *HistoryService) *UndoService {
+	return &UndoService{
+		configService:  configService,
+		serviceManager: serviceManager,
+		watchService:   watchService,
+		outboxService:  outboxService,
+		history:        history,
+	}
+}
+
+// SetIdempotencyService 设置幂等键服务，撤销成功后提交使用的幂等键失效
+func (s *UndoService) SetIdempotencyService(idempotency *IdempotencyService) {
+	s.idempotency = idempotency
+}
+
+// Undo 撤销指定的提交，返回每个步骤的执行结果
+// 删除记录失败时不执行后续步骤，其他步骤失败时继续执行并在结果中返回错误
+func (s *UndoService) Undo(submissionID string) (models.UndoResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.history.Get(submissionID)
+	if !ok {
+		return models.UndoResult{}, ErrUndoNotFound
+	}
+	switch entry.Status {
+	case models.SubmissionCreated, models.SubmissionQueued:
+	case models.SubmissionUndone:
+		return models.UndoResult{}, ErrUndoAlreadyDone
+	default:
+		return models.UndoResult{}, ErrUndoNotAllowed
+	}
+
+	config := s.configService.GetConfig()
+	window := DefaultUndoWindow
+	if config.UndoWindowMinutes > 0 {
+		window = time.Duration(config.UndoWindowMinutes) * time.Minute
+	}
+	if time.Since(entry.Time) > window {
+		return models.UndoResult{}, fmt.Errorf("%w（%d 分钟）", ErrUndoExpired, int(window/time.Minute))
+	}
+
+	result := models.UndoResult{SubmissionID: entry.ID, RecordID: entry.RecordID}
+
+	if entry.Status == models.SubmissionQueued {
+		err := ErrOutboxNotFound
+		if s.outboxService != nil {
+			err = s.outboxService.Discard(entry.OutboxID)
+		}
+		if err == nil {
+			result.Steps = append(result.Steps, models.UndoStep{Step: models.UndoStepDiscardOutbox, Status: models.ActionSucceeded, ID: entry.OutboxID})
+			result.Undone = true
+			s.markUndone(entry.ID)
+			logInfo("↩️ 已撤销待提交记录: %s", entry.OutboxID)
+			return result, nil
+		}
+		if !errors.Is(err, ErrOutboxNotFound) {
+			result.Steps = append(result.Steps, models.UndoStep{Step: models.UndoStepDiscardOutbox, Status: models.ActionFailed, ID: entry.OutboxID, Error: err.Error()})
+			return result, nil
+		}
+
+		// 队列项已不存在，可能已在后台提交成功
+		entry, _ = s.history.Get(submissionID)
+		if entry.Status != models.SubmissionCreated {
+			result.Steps = append(result.Steps, models.UndoStep{Step: models.UndoStepDiscardOutbox, Status: models.ActionFailed, ID: entry.OutboxID, Error: err.Error()})
+			return result, nil
+		}
+		result.RecordID = entry.RecordID
+	}
+
+	larkService := s.serviceManager.GetLarkService(config.AppID, config.AppSecret)
+	if larkService == nil {
+		return models.UndoResult{}, fmt.Errorf("飞书应用信息未配置")
+	}
+
+	// 先取消检测，避免删除记录后检测任务在记录不存在时发送通知
+	var cancelled *models.Watch
+	if s.watchService != nil {
+		if watch, ok := s.watchService.GetWatch(entry.RecordID); ok && s.watchService.CancelWatch(entry.RecordID) {
+			cancelled = &watch
+		}
+	}
+
+	// 删除失败时恢复检测任务并保留通知，用户可以稍后重试
+	if err := larkService.DeleteRecord(entry.AppToken, entry.TableID, entry.RecordID); err != nil {
+		logError("❌ 撤销提交时删除记录 %s 失败: %v", entry.RecordID, err)
+		if cancelled != nil {
+			s.watchService.RestoreWatch(*cancelled)
+		}
+		result.Steps = append(result.Steps, undoStep(models.UndoStepDeleteRecord, entry.RecordID, err))
+		return result, nil
+	}
+	if cancelled != nil {
+		result.Steps = append(result.Steps, undoStep(models.UndoStepCancelWatch, entry.RecordID, nil))
+	} else {
+		result.Steps = append(result.Steps, models.UndoStep{Step: models.UndoStepCancelWatch, Status: models.ActionSkipped, ID: entry.RecordID, Error: "没有检测中的任务"})
+	}
+	result.Steps = append(result.Steps, undoStep(models.UndoStepDeleteRecord, entry.RecordID, nil))
+	result.Undone = true
+
+	messageIDs := append([]string(nil), entry.ProgressMessages...)
+	if entry.Notification != nil && entry.Notification.ID != "" {
+		messageIDs = append(messageIDs, entry.Notification.ID)
+	}
+	for _, messageID := range messageIDs {
+		result.Steps = append(result.Steps, undoStep(models.UndoStepRecallMessage, messageID, larkService.RecallMessage(messageID)))
+	}
+	if len(messageIDs) == 0 {
+		result.Steps = append(result.Steps, models.UndoStep{Step: models.UndoStepRecallMessage, Status: models.ActionSkipped, Error: "尚未发送通知"})
+	}
+
+	if entry.Task != nil && entry.Task.ID != "" {
+		result.Steps = append(result.Steps, undoStep(models.UndoStepDeleteTask, entry.Task.ID, larkService.DeleteTask(entry.Task.ID)))
+	} else {
+		result.Steps = append(result.Steps, models.UndoStep{Step: models.UndoStepDeleteTask, Status: models.ActionSkipped, Error: "尚未创建任务"})
+	}
+
+	s.markUndone(entry.ID)
+	logInfo("↩️ 已撤销提交 %s，记录ID: %s", entry.ID, entry.RecordID)
+	return result, nil
+}
+
+// markUndone 将提交记录标记为已撤销，并使提交使用的幂等键失效，避免重试请求返回已撤销的结果
+func (s *UndoService) markUndone(id string) {
+	if entry, ok := s.history.Get(id); ok && entry.IdempotencyKey != "" && s.idempotency != nil {
+		s.idempotency.Forget(entry.IdempotencyKey)
+	}
+
+	now := time.Now()
+	s.history.Update(id, func(entry *models.Submission) {
+		entry.Status = models.SubmissionUndone
+		entry.Error = ""
+		entry.UndoneAt = &now
+	})
+}
+
+// undoStep 根据执行错误生成撤销步骤的结果
+func undoStep(step, id string, err error) models.UndoStep {
+	result := models.UndoStep{Step: step, Status: models.ActionSucceeded, ID: id}
+	if err != nil {
+		result.Status = models.ActionFailed
+		result.Error = err.Error()
+	}
+	return result
+}
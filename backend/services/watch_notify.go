@@ -0,0 +1,306 @@
+package services
+
+import (
+	"fmt"
+	"lark-record/models"
+	"strings"
+	"time"
+)
+
+// onCompleted 记录满足完成条件后发送群消息并创建任务
+func (s *WatchService) onCompleted(larkService *LarkService, config *models.Config, watch models.Watch, rule models.ConditionGroup, fields, fieldValues map[string]interface{}) {
+	logInfo("✅ 记录ID %s 已满足完成条件！", watch.RecordID)
+
+	// 准备发送消息的内容，将表格名称放在第一行
+	conjunction := "全部满足"
+	if strings.EqualFold(rule.Conjunction, models.ConjunctionOr) {
+		conjunction = "满足任一"
+	}
+	message := fmt.Sprintf("📊 表格：%s\n\n📢 记录ID %s 已满足完成条件！\n\n完成条件（%s）：\n", watch.TableName, watch.RecordID, conjunction)
+	for _, cond := range rule.Conditions {
+		mark := "⬜"
+		if evaluateCondition(cond, fields[cond.FieldName]) {
+			mark = "✅"
+		}
+		message += fmt.Sprintf("%s %s\n", mark, describeCondition(cond))
+	}
+	message += "\n检测字段内容：\n"
+	for _, fieldName := range ConditionFieldNames(rule) {
+		if value, ok := fieldValues[fieldName]; ok {
+			message += fmt.Sprintf("%s: %s\n", fieldName, formatFieldValue(fieldName, value))
+		}
+	}
+
+	// 发送消息
+	if config.GroupChatID != "" {
+		messageID, err := larkService.SendMessage(config.GroupChatID, message)
+		if err != nil {
+			logError("❌ 发送消息失败: %v", err)
+		} else {
+			logInfo("✅ 消息发送成功！")
+		}
+		s.updateHistory(watch.RecordID, func(entry *models.Submission) {
+			entry.Notification = newActionResult(messageID, err)
+		})
+	}
+
+	// 检查是否需要创建任务
+	for _, table := range config.Tables {
+		if table.AppToken == watch.AppToken && table.TableID == watch.TableID {
+			logInfo("🔄 开始创建任务...")
+			taskGUID, err := larkService.CreateTaskFromFieldValues(table, fieldValues)
+			if err != nil {
+				logError("❌ 创建任务失败: %v", err)
+			} else {
+				logInfo("✅ 任务创建成功！")
+			}
+			if table.Task.Enabled || table.CreateTask {
+				s.updateHistory(watch.RecordID, func(entry *models.Submission) {
+					entry.Task = newActionResult(taskGUID, err)
+				})
+			}
+			break
+		}
+	}
+}
+
+// onFieldsSatisfied 检测字段新满足条件时发送进度通知，每个字段一条消息
+// 只检测是否有值的字段通知已填写，配置了条件的字段通知满足的条件和当前值
+func (s *WatchService) onFieldsSatisfied(larkService *LarkService, config *models.Config, watch models.Watch, rule models.ConditionGroup, satisfied []string, fields map[string]interface{}) {
+	if config.GroupChatID == "" {
+		return
+	}
+
+	// watch.FilledFields 已包含本次新满足条件的字段，逐条通知时按顺序累计进度
+	before := len(watch.FilledFields) - len(satisfied)
+	for i, fieldName := range satisfied {
+		done := watch.FilledFields[:before+i+1]
+		value := "空"
+		if !isEmptyFieldValue(fields[fieldName]) {
+			value = formatFieldValue(fieldName, fields[fieldName])
+		}
+
+		var conditions []string
+		onlyNotEmpty := true
+		for _, cond := range rule.Conditions {
+			if cond.FieldName != fieldName {
+				continue
+			}
+			conditions = append(conditions, describeCondition(cond))
+			if cond.Operator != models.OperatorIsNotEmpty {
+				onlyNotEmpty = false
+			}
+		}
+
+		event := fmt.Sprintf("字段「%s」已填写：%s", fieldName, value)
+		if !onlyNotEmpty {
+			event = fmt.Sprintf("字段「%s」已满足条件「%s」（当前值: %s）", fieldName, strings.Join(conditions, "，"), value)
+		}
+		message := fmt.Sprintf("📊 表格：%s\n\n📝 记录ID %s 的%s\n\n进度：%d/%d（已满足：%s）",
+			watch.TableName, watch.RecordID, event,
+			len(done), len(watch.CheckFields), strings.Join(done, "、"))
+
+		messageID, err := larkService.SendMessage(config.GroupChatID, message)
+		if err != nil {
+			logError("❌ 发送字段进度通知失败: %v", err)
+			continue
+		}
+		logInfo("✅ 记录ID %s 字段「%s」进度通知发送成功！", watch.RecordID, fieldName)
+		if messageID != "" {
+			s.updateHistory(watch.RecordID, func(entry *models.Submission) {
+				entry.ProgressMessages = append(entry.ProgressMessages, messageID)
+			})
+		}
+	}
+}
+
+// onStalled 检测超时后按表格配置发送通知，列出尚未满足条件的字段和等待时长
+func (s *WatchService) onStalled(larkService *LarkService, config *models.Config, watch models.Watch) {
+	table, _ := findTableConfig(config, watch.AppToken, watch.TableID)
+	action := table.TimeoutAction
+	if !action.Enabled {
+		return
+	}
+
+	chatID := action.ChatID
+	if chatID == "" {
+		chatID = config.GroupChatID
+	}
+	if chatID == "" {
+		logError("❌ 记录ID %s 检测超时，但未配置接收通知的群", watch.RecordID)
+		return
+	}
+
+	rule := watch.Rule
+	if len(rule.Conditions) == 0 {
+		rule = DefaultCompletionRule(watch.CheckFields)
+	}
+
+	message := fmt.Sprintf("📊 表格：%s\n\n⏰ 记录ID %s 已等待 %s，仍未满足完成条件，已停止检测。\n\n",
+		watch.TableName, watch.RecordID, formatWaitDuration(time.Since(watch.CreatedAt)))
+
+	// 获取记录最新的字段值，用于列出未满足的条件和需要@的人员
+	fields, err := larkService.GetRecord(watch.AppToken, watch.TableID, watch.RecordID)
+	if err != nil {
+		logError("⚠️ 获取记录ID %s 的字段失败: %v", watch.RecordID, err)
+		message += "检测字段：" + strings.Join(watch.CheckFields, ", ") + "\n"
+	} else {
+		message += "未满足的条件：\n"
+		for _, cond := range rule.Conditions {
+			value := fields[cond.FieldName]
+			if evaluateCondition(cond, value) {
+				continue
+			}
+			message += "- " + describeUnmetCondition(cond, value) + "\n"
+		}
+
+		mentions := mentionUsers(fields, action.MentionFields)
+		if mentions != "" {
+			message += "\n" + mentions
+		}
+	}
+
+	if watch.LastError != "" {
+		message += fmt.Sprintf("\n最近一次检测错误：%s\n", watch.LastError)
+	}
+
+	messageID, err := larkService.SendMessage(chatID, message)
+	if err != nil {
+		logError("❌ 发送超时通知失败: %v", err)
+	} else {
+		logInfo("✅ 超时通知发送成功！")
+	}
+	s.updateHistory(watch.RecordID, func(entry *models.Submission) {
+		entry.Notification = newActionResult(messageID, err)
+	})
+}
+
+// describeCondition 描述条件，不为空的条件描述为已填写
+func describeCondition(cond models.Condition) string {
+	switch cond.Operator {
+	case models.OperatorIsNotEmpty:
+		return fmt.Sprintf("%s 已填写", cond.FieldName)
+	case models.OperatorIsEmpty:
+		return fmt.Sprintf("%s 为空", cond.FieldName)
+	}
+	return fmt.Sprintf("%s %s %s", cond.FieldName, cond.Operator, strings.Join(cond.Value, ", "))
+}
+
+// describeUnmetCondition 描述未满足的条件
+func describeUnmetCondition(cond models.Condition, value interface{}) string {
+	if cond.Operator == models.OperatorIsNotEmpty {
+		return fmt.Sprintf("%s: 尚未填写", cond.FieldName)
+	}
+
+	current := "空"
+	if !isEmptyFieldValue(value) {
+		current = formatFieldValue(cond.FieldName, value)
+	}
+	return fmt.Sprintf("%s: 需满足 %s %s（当前值: %s）", cond.FieldName, cond.Operator, strings.Join(cond.Value, ", "), current)
+}
+
+// mentionUsers 生成@人员字段中用户的消息文本
+func mentionUsers(fields map[string]interface{}, mentionFields []string) string {
+	var mentions []string
+	seen := make(map[string]bool)
+	for _, fieldName := range mentionFields {
+		var users []interface{}
+		switch v := fields[fieldName].(type) {
+		case []interface{}:
+			users = v
+		case map[string]interface{}:
+			users = []interface{}{v}
+		}
+
+		for _, item := range users {
+			user, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			id, _ := user["id"].(string)
+			if id == "" || seen[id] {
+				continue
+			}
+			seen[id] = true
+			name, _ := user["name"].(string)
+			mentions = append(mentions, fmt.Sprintf("<at user_id=\"%s\">%s</at>", id, name))
+		}
+	}
+	return strings.Join(mentions, " ")
+}
+
+// formatWaitDuration 将等待时长格式化为天/小时/分钟
+func formatWaitDuration(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%d天", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%d小时", hours))
+	}
+	if minutes > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%d分钟", minutes))
+	}
+	return strings.Join(parts, "")
+}
+
+// formatFieldValue 将字段值格式化为便于阅读的文本
+func formatFieldValue(fieldName string, value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []interface{}:
+		// 处理数组类型的值（如多选、人员）
+		var parts []string
+		for _, item := range v {
+			if userMap, ok := item.(map[string]interface{}); ok {
+				parts = append(parts, formatUserInfo(userMap))
+			} else {
+				parts = append(parts, fmt.Sprintf("%v", item))
+			}
+		}
+		return strings.Join(parts, ", ")
+	case float64:
+		// 尝试将float64值作为时间戳处理
+		// 飞书时间戳通常是毫秒级，且在合理的时间范围内（1970年至今）
+		timestamp := int64(v)
+		if timestamp > 0 && timestamp < 3250368000000 { // 小于2100年的毫秒时间戳
+			// 转换为东八区时间
+			t := time.Unix(timestamp/1000, 0).In(time.FixedZone("Asia/Shanghai", 8*3600))
+			return t.Format("2006-01-02 15:04:05")
+		}
+		return fmt.Sprintf("%v", v)
+	case map[string]interface{}:
+		// 处理单个用户类型的值
+		if strings.Contains(fieldName, "人") || (v["id"] != nil && (v["name"] != nil || v["en_name"] != nil)) {
+			userInfo := formatUserInfo(v)
+			if userInfo == "" {
+				userInfo = "未知用户"
+			}
+			return userInfo
+		}
+		// 其他复杂对象，简化显示
+		return "[复杂对象]"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// formatUserInfo 提取用户信息
+func formatUserInfo(userMap map[string]interface{}) string {
+	var parts []string
+	if enName, ok := userMap["en_name"].(string); ok && enName != "" {
+		parts = append(parts, fmt.Sprintf("en_name:%s", enName))
+	}
+	if id, ok := userMap["id"].(string); ok && id != "" {
+		parts = append(parts, fmt.Sprintf("id:%s", id))
+	}
+	if name, ok := userMap["name"].(string); ok && name != "" {
+		parts = append(parts, fmt.Sprintf("name:%s", name))
+	}
+	return strings.Join(parts, " ")
+}
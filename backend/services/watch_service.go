@@ -0,0 +1,762 @@
+package services
+
+import (
+	"fmt"
+	"lark-record/models"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 检测任务调度配置，表格未配置轮询策略时使用这些默认值
+const (
+	DefaultWatchInitialDelay  = 10 * time.Second // 首次检测延迟，避免数据同步延迟
+	DefaultWatchBaseInterval  = 10 * time.Second // 基础检测间隔
+	DefaultWatchMaxInterval   = 5 * time.Minute  // 最大检测间隔
+	DefaultWatchBackoffFactor = 2.0              // 检测间隔退避系数
+	DefaultWatchMaxChecks     = 20               // 最大检测次数
+	watchSchedulerTick        = 1 * time.Second  // 调度器扫描间隔
+	watchEventFreshness       = 30 * time.Minute // 数据表在该时长内收到过记录变更事件时，轮询只作为兜底
+)
+
+// WatchService 字段完成检测服务
+// 检测任务持久化到本地文件，服务重启后自动恢复并继续检测
+type WatchService struct {
+	mu             sync.Mutex
+	store          *WatchStore
+	watches        map[string]*models.Watch // key: recordID
+	running        map[string]bool          // 正在检测中的记录
+	eventTables    map[string]time.Time     // 最近收到记录变更事件的时间，key: appToken/tableID
+	configService  *ConfigService
+	serviceManager *ServiceManager
+	history        *HistoryService
+	wake           chan struct{}
+}
+
+// NewWatchService 创建新的检测服务
+func NewWatchService(storePath string, configService *ConfigService, serviceManager *ServiceManager) *WatchService {
+	return &WatchService{
+		store:          NewWatchStore(storePath),
+		watches:        make(map[string]*models.Watch),
+		running:        make(map[string]bool),
+		eventTables:    make(map[string]time.Time),
+		configService:  configService,
+		serviceManager: serviceManager,
+		wake:           make(chan struct{}, 1),
+	}
+}
+
+// SetHistoryService 设置提交记录服务，检测结果、完成通知和任务的执行结果会更新到提交记录中
+func (s *WatchService) SetHistoryService(history *HistoryService) {
+	s.history = history
+}
+
+// updateHistory 更新记录对应的提交记录，未设置提交记录服务时不做处理
+func (s *WatchService) updateHistory(recordID string, update func(*models.Submission)) {
+	if s.history != nil {
+		s.history.UpdateByRecordID(recordID, update)
+	}
+}
+
+// setWatchOutcome 在提交记录中更新字段检测结果
+func (s *WatchService) setWatchOutcome(recordID, outcome string) {
+	s.updateHistory(recordID, func(entry *models.Submission) {
+		entry.WatchOutcome = outcome
+	})
+}
+
+// Start 加载已持久化的检测任务并启动调度器
+func (s *WatchService) Start() {
+	watches, err := s.store.Load()
+	if err != nil {
+		logError("加载检测任务失败: %v", err)
+	}
+
+	s.mu.Lock()
+	for _, w := range watches {
+		s.watches[w.RecordID] = w
+	}
+	s.mu.Unlock()
+
+	if len(watches) > 0 {
+		logInfo("🔄 恢复了 %d 个未完成的检测任务", len(watches))
+	}
+
+	// 重新订阅恢复任务所在表格的记录变更事件
+	subscribed := make(map[string]bool)
+	for _, w := range watches {
+		if !subscribed[w.AppToken] {
+			subscribed[w.AppToken] = true
+			s.subscribeEvents(w.AppToken)
+		}
+	}
+
+	go s.run()
+}
+
+// AddWatch 为新记录创建检测任务
+// 表格未配置检测字段时不创建任务，返回nil
+func (s *WatchService) AddWatch(appToken, tableID, recordID string) *models.Watch {
+	watches := s.AddWatches(appToken, tableID, []string{recordID})
+	if len(watches) == 0 {
+		return nil
+	}
+	return &watches[0]
+}
+
+// AddWatches 为同一数据表中新增的多条记录创建检测任务，只写入一次检测任务文件
+// 表格未配置检测字段时不创建任务，已在检测中的记录返回原有任务
+func (s *WatchService) AddWatches(appToken, tableID string, recordIDs []string) []models.Watch {
+	config := s.configService.GetConfig()
+	table, _ := findTableConfig(config, appToken, tableID)
+	rule := CompletionRuleForTable(table)
+	if len(rule.Conditions) == 0 || len(recordIDs) == 0 {
+		return nil
+	}
+
+	policy := resolvePollingPolicy(table.Polling)
+	firstCheck := time.Now().Add(time.Duration(policy.InitialDelaySeconds) * time.Second)
+
+	added := make([]models.Watch, 0, len(recordIDs))
+	s.mu.Lock()
+	for _, recordID := range recordIDs {
+		// 已在检测中的记录保留原有任务，避免重置检测次数、下次检测时间和已通知的进度
+		if existing, ok := s.watches[recordID]; ok {
+			added = append(added, *existing)
+			logInfo("ℹ️ 记录ID %s 已在检测中，保留原有检测任务", recordID)
+			continue
+		}
+		watch := newWatch(table, rule, policy, recordID, firstCheck)
+		s.watches[recordID] = watch
+		added = append(added, *watch)
+		logInfo("🔍 开始检测记录ID %s 的字段: %v", recordID, watch.CheckFields)
+	}
+	s.persistLocked()
+	s.mu.Unlock()
+
+	s.subscribeEvents(appToken)
+	return added
+}
+
+// WatchRecords 为已存在的记录创建检测任务，并立即进行首次检测
+// 已在检测中的记录会被跳过，返回新创建的检测任务和跳过的记录ID
+func (s *WatchService) WatchRecords(appToken, tableID string, recordIDs []string) ([]models.Watch, []string, error) {
+	config := s.configService.GetConfig()
+	table, ok := findTableConfig(config, appToken, tableID)
+	if !ok {
+		return nil, nil, fmt.Errorf("未找到表格配置: %s/%s", appToken, tableID)
+	}
+	rule := CompletionRuleForTable(table)
+	if len(rule.Conditions) == 0 {
+		return nil, nil, fmt.Errorf("表格 %s 未配置检测字段或完成条件", table.Name)
+	}
+
+	policy := resolvePollingPolicy(table.Polling)
+	now := time.Now()
+
+	added := []models.Watch{}
+	skipped := []string{}
+	s.mu.Lock()
+	for _, recordID := range recordIDs {
+		if recordID == "" {
+			continue
+		}
+		if _, exists := s.watches[recordID]; exists {
+			skipped = append(skipped, recordID)
+			continue
+		}
+		watch := newWatch(table, rule, policy, recordID, now)
+		s.watches[recordID] = watch
+		added = append(added, *watch)
+	}
+	if len(added) > 0 {
+		s.persistLocked()
+	}
+	s.mu.Unlock()
+
+	if len(added) > 0 {
+		logInfo("🔍 为 %d 条已存在的记录创建检测任务，表格: %s", len(added), table.Name)
+		s.subscribeEvents(appToken)
+		s.wakeScheduler()
+	}
+	return added, skipped, nil
+}
+
+// WatchMatchingRecords 查询数据表中符合条件的记录并为其创建检测任务
+// createdWithin大于0时只处理该时长内创建的记录，limit限制最多处理的记录数
+// 数据表有创建时间字段时按创建时间在飞书端筛选，找到limit条记录后停止分页查询
+func (s *WatchService) WatchMatchingRecords(appToken, tableID string, filter *models.ConditionGroup, createdWithin time.Duration, limit int) ([]models.Watch, []string, error) {
+	config := s.configService.GetConfig()
+	larkService := s.serviceManager.GetLarkService(config.AppID, config.AppSecret)
+	if larkService == nil {
+		return nil, nil, fmt.Errorf("请先配置飞书应用信息")
+	}
+
+	cutoff := time.Now().Add(-createdWithin)
+	query := models.RecordSearchRequest{Filter: filter, PageSize: SearchRecordsMaxPageSize}
+	if createdWithin > 0 {
+		query.Filter = createdAfterFilter(larkService, appToken, tableID, filter, cutoff)
+	}
+
+	var recordIDs []string
+	for {
+		page, err := larkService.SearchRecords(appToken, tableID, query)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, record := range page.Items {
+			// 飞书按日期筛选，精确的创建时间在本地判断
+			if createdWithin > 0 && record.CreatedTime < cutoff.UnixMilli() {
+				continue
+			}
+			recordIDs = append(recordIDs, record.RecordID)
+			if limit > 0 && len(recordIDs) >= limit {
+				break
+			}
+		}
+
+		if (limit > 0 && len(recordIDs) >= limit) || !page.HasMore || page.PageToken == "" {
+			break
+		}
+		query.PageToken = page.PageToken
+	}
+
+	logInfo("🔎 表格 %s 中有 %d 条记录符合条件", tableID, len(recordIDs))
+	return s.WatchRecords(appToken, tableID, recordIDs)
+}
+
+// createdAfterFilter 在查询条件中加入创建时间不早于cutoff所在日期前一天的条件
+// 数据表没有创建时间字段或条件使用"或"连接时无法加入，返回原条件，由调用方在本地过滤
+func createdAfterFilter(larkService *LarkService, appToken, tableID string, filter *models.ConditionGroup, cutoff time.Time) *models.ConditionGroup {
+	if filter != nil && len(filter.Conditions) > 1 && filter.Conjunction == models.ConjunctionOr {
+		return filter
+	}
+
+	tableFields, err := larkService.GetTableFields(appToken, tableID)
+	if err != nil {
+		logError("⚠️ 获取表格字段失败，在本地按创建时间过滤: %v", err)
+		return filter
+	}
+	for _, field := range tableFields {
+		if fieldKind(field) != "CreatedTime" {
+			continue
+		}
+
+		group := models.ConditionGroup{Conjunction: models.ConjunctionAnd}
+		if filter != nil {
+			group.Conditions = append(group.Conditions, filter.Conditions...)
+		}
+		// 飞书的日期条件按天比较，提前一天以免时区差异漏掉记录
+		group.Conditions = append(group.Conditions, models.Condition{
+			FieldName: field.FieldName,
+			Operator:  models.OperatorIsGreaterEqual,
+			Value:     []string{"ExactDate", strconv.FormatInt(cutoff.AddDate(0, 0, -1).UnixMilli(), 10)},
+		})
+		return &group
+	}
+	return filter
+}
+
+// RecordEventReceived 记录数据表收到了飞书的记录变更事件
+// 之后该数据表的检测任务改为按最大检测间隔轮询，记录变化由事件触发立即检测
+func (s *WatchService) RecordEventReceived(appToken, tableID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.eventTables[appToken+"/"+tableID] = time.Now()
+}
+
+// HandleRecordChanged 处理记录变更，立即检测匹配的检测任务
+// 返回被触发检测的任务数量，触发的检测不计入检测次数，未变更的任务继续按轮询计划检测
+func (s *WatchService) HandleRecordChanged(appToken, tableID string, recordIDs []string) int {
+	s.mu.Lock()
+	matched := 0
+	now := time.Now()
+	for _, recordID := range recordIDs {
+		w, ok := s.watches[recordID]
+		if !ok || w.AppToken != appToken || w.TableID != tableID {
+			continue
+		}
+		w.NextCheckAt = now
+		w.Triggered = true
+		matched++
+	}
+	if matched > 0 {
+		s.persistLocked()
+	}
+	s.mu.Unlock()
+
+	if matched > 0 {
+		logInfo("📨 记录变更事件命中 %d 个检测任务，立即检测", matched)
+		s.wakeScheduler()
+	}
+	return matched
+}
+
+// HandleRecordDeleted 处理记录删除，取消对应的检测任务
+// 返回被取消的任务数量
+func (s *WatchService) HandleRecordDeleted(appToken, tableID string, recordIDs []string) int {
+	s.mu.Lock()
+	var removed []string
+	for _, recordID := range recordIDs {
+		w, ok := s.watches[recordID]
+		if !ok || w.AppToken != appToken || w.TableID != tableID {
+			continue
+		}
+		delete(s.watches, recordID)
+		removed = append(removed, recordID)
+	}
+	if len(removed) > 0 {
+		s.persistLocked()
+	}
+	s.mu.Unlock()
+
+	if len(removed) > 0 {
+		logInfo("🗑️ 记录已删除，取消 %d 个检测任务", len(removed))
+	}
+	// 只更新被取消的检测任务，已完成或超时的检测结果保持不变
+	for _, recordID := range removed {
+		s.setWatchOutcome(recordID, models.WatchOutcomeCancelled)
+	}
+	return len(removed)
+}
+
+// subscribeEvents 在配置了事件订阅时异步订阅多维表格的记录变更事件
+func (s *WatchService) subscribeEvents(appToken string) {
+	config := s.configService.GetConfig()
+	if config.EventVerificationToken == "" {
+		return
+	}
+
+	larkService := s.serviceManager.GetLarkService(config.AppID, config.AppSecret)
+	if larkService == nil {
+		return
+	}
+
+	go func() {
+		if err := larkService.SubscribeBitableEvents(appToken); err != nil {
+			logError("⚠️ 订阅记录变更事件失败，将仅使用轮询检测: %v", err)
+		}
+	}()
+}
+
+// wakeScheduler 唤醒调度器，无需等待下一次扫描
+func (s *WatchService) wakeScheduler() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// ListWatches 获取所有未完成的检测任务，按创建时间排序
+func (s *WatchService) ListWatches() []models.Watch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	watches := make([]models.Watch, 0, len(s.watches))
+	for _, w := range s.watches {
+		watches = append(watches, *w)
+	}
+	sort.Slice(watches, func(i, j int) bool {
+		return watches[i].CreatedAt.Before(watches[j].CreatedAt)
+	})
+	return watches
+}
+
+// GetWatch 获取指定记录的检测任务
+func (s *WatchService) GetWatch(recordID string) (models.Watch, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.watches[recordID]
+	if !ok {
+		return models.Watch{}, false
+	}
+	return *w, true
+}
+
+// CancelWatch 取消指定记录的检测任务
+func (s *WatchService) CancelWatch(recordID string) bool {
+	s.mu.Lock()
+	if _, ok := s.watches[recordID]; !ok {
+		s.mu.Unlock()
+		return false
+	}
+	delete(s.watches, recordID)
+	s.persistLocked()
+	s.mu.Unlock()
+
+	logInfo("🛑 已取消记录ID %s 的字段检测", recordID)
+	s.setWatchOutcome(recordID, models.WatchOutcomeCancelled)
+	return true
+}
+
+// RestoreWatch 恢复被取消的检测任务，用于撤销失败时继续检测
+func (s *WatchService) RestoreWatch(watch models.Watch) {
+	s.mu.Lock()
+	if _, ok := s.watches[watch.RecordID]; ok {
+		s.mu.Unlock()
+		return
+	}
+	s.watches[watch.RecordID] = &watch
+	s.persistLocked()
+	s.mu.Unlock()
+
+	logInfo("↩️ 已恢复记录ID %s 的字段检测", watch.RecordID)
+	s.setWatchOutcome(watch.RecordID, models.WatchOutcomeWatching)
+}
+
+// CheckNow 立即触发一次检测，不改变已检测次数
+func (s *WatchService) CheckNow(recordID string) (models.Watch, bool) {
+	s.mu.Lock()
+	w, ok := s.watches[recordID]
+	if !ok {
+		s.mu.Unlock()
+		return models.Watch{}, false
+	}
+	w.NextCheckAt = time.Now()
+	w.Triggered = true
+	s.persistLocked()
+	watch := *w
+	s.mu.Unlock()
+
+	s.wakeScheduler()
+	return watch, true
+}
+
+// run 调度循环，定期取出到期的检测任务执行
+func (s *WatchService) run() {
+	ticker := time.NewTicker(watchSchedulerTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		}
+		s.dispatchDue()
+	}
+}
+
+// dispatchDue 执行所有到期的检测任务
+// 到期任务按多维表格和数据表分组，每组通过批量获取接口一次拉取所有记录
+func (s *WatchService) dispatchDue() {
+	now := time.Now()
+
+	s.mu.Lock()
+	groups := make(map[string][]models.Watch)
+	for id, w := range s.watches {
+		if s.running[id] || w.NextCheckAt.After(now) {
+			continue
+		}
+		s.running[id] = true
+		key := w.AppToken + "/" + w.TableID
+		groups[key] = append(groups[key], *w)
+	}
+	s.mu.Unlock()
+
+	for _, group := range groups {
+		for start := 0; start < len(group); start += BatchGetRecordsMaxSize {
+			end := start + BatchGetRecordsMaxSize
+			if end > len(group) {
+				end = len(group)
+			}
+			go s.checkBatch(group[start:end])
+		}
+	}
+}
+
+// checkBatch 批量获取同一数据表中的记录，并逐条处理检测结果
+func (s *WatchService) checkBatch(watches []models.Watch) {
+	defer func() {
+		s.mu.Lock()
+		for _, watch := range watches {
+			delete(s.running, watch.RecordID)
+		}
+		s.mu.Unlock()
+	}()
+
+	config := s.configService.GetConfig()
+	larkService := s.serviceManager.GetLarkService(config.AppID, config.AppSecret)
+	if larkService == nil {
+		// 无法发送超时通知，仅记录停止检测
+		for _, watch := range watches {
+			s.reschedule(watch.RecordID, fmt.Errorf("飞书应用信息未配置"))
+		}
+		return
+	}
+
+	recordIDs := make([]string, 0, len(watches))
+	for _, watch := range watches {
+		recordIDs = append(recordIDs, watch.RecordID)
+	}
+
+	logInfo("🔍 批量检查 %d 条记录的字段状态 (表格: %s)", len(recordIDs), watches[0].TableID)
+	records, _, err := larkService.BatchGetRecords(watches[0].AppToken, watches[0].TableID, recordIDs)
+	if err != nil {
+		logError("❌ 批量获取记录失败: %v", err)
+		for _, watch := range watches {
+			s.handleCheckError(larkService, config, watch, err)
+		}
+		return
+	}
+
+	// 已删除、无权访问以及接口未返回的记录都停止检测
+	for _, watch := range watches {
+		fields, ok := records[watch.RecordID]
+		if !ok {
+			if s.remove(watch.RecordID) {
+				logError("❌ 记录ID %s 已不存在或无权访问，停止检测", watch.RecordID)
+				s.setWatchOutcome(watch.RecordID, models.WatchOutcomeMissing)
+			}
+			continue
+		}
+		s.handleRecord(larkService, config, watch, fields)
+	}
+}
+
+// handleCheckError 处理获取记录失败的检测任务，可重试的错误会安排下次检测
+func (s *WatchService) handleCheckError(larkService *LarkService, config *models.Config, watch models.Watch, err error) {
+	// 检查是否是网络错误或飞书API错误，决定是否重试
+	if !isRetryableWatchError(err) {
+		if s.remove(watch.RecordID) {
+			logError("❌ 记录ID %s 检查字段状态失败，错误不可重试，停止检测", watch.RecordID)
+			s.setWatchOutcome(watch.RecordID, models.WatchOutcomeFailed)
+		}
+		return
+	}
+	if stalled, ok := s.reschedule(watch.RecordID, err); ok {
+		s.setWatchOutcome(watch.RecordID, models.WatchOutcomeStalled)
+		s.onStalled(larkService, config, stalled)
+	}
+}
+
+// handleRecord 根据记录当前的字段值判断是否完成，并发送对应通知
+func (s *WatchService) handleRecord(larkService *LarkService, config *models.Config, watch models.Watch, fields map[string]interface{}) {
+	// 旧版本持久化的检测任务没有完成条件，使用检测字段生成默认条件
+	rule := watch.Rule
+	if len(rule.Conditions) == 0 {
+		rule = DefaultCompletionRule(watch.CheckFields)
+	}
+
+	completed, fieldValues := EvaluateCompletion(rule, fields)
+
+	// 先发送本次新满足条件字段的进度通知，完成时最后满足条件的字段也会通知
+	if watch.Progress {
+		if satisfied, updated := s.markSatisfied(watch.RecordID, SatisfiedFields(rule, fields)); len(satisfied) > 0 {
+			s.onFieldsSatisfied(larkService, config, updated, rule, satisfied, fields)
+		}
+	}
+
+	if !completed {
+		logInfo("⏳ 记录ID %s 尚未满足完成条件，继续检测...", watch.RecordID)
+		if stalled, ok := s.reschedule(watch.RecordID, nil); ok {
+			s.setWatchOutcome(watch.RecordID, models.WatchOutcomeStalled)
+			s.onStalled(larkService, config, stalled)
+		}
+		return
+	}
+
+	// 检测期间任务已被取消（如撤销提交）时不再发送完成通知
+	if !s.remove(watch.RecordID) {
+		logInfo("🛑 记录ID %s 的检测任务已取消，不发送完成通知", watch.RecordID)
+		return
+	}
+	s.setWatchOutcome(watch.RecordID, models.WatchOutcomeCompleted)
+	s.onCompleted(larkService, config, watch, rule, fields, fieldValues)
+}
+
+// reschedule 增加检测次数并计算下次检测时间，达到最大次数或截止时间后停止检测
+// 事件或手动触发的检测不增加检测次数；数据表最近收到过事件时使用最大检测间隔
+// 停止检测时返回该检测任务和true
+func (s *WatchService) reschedule(recordID string, checkErr error) (models.Watch, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.watches[recordID]
+	if !ok {
+		return models.Watch{}, false
+	}
+
+	if checkErr != nil {
+		w.LastError = checkErr.Error()
+	} else {
+		w.LastError = ""
+	}
+
+	policy := resolvePollingPolicy(w.Policy)
+	interval := watchInterval(policy, w.Attempts)
+	if last, ok := s.eventTables[w.AppToken+"/"+w.TableID]; ok && time.Since(last) < watchEventFreshness {
+		// 事件订阅正常时记录变化会立即检测，轮询只作为兜底
+		interval = time.Duration(policy.MaxIntervalSeconds) * time.Second
+	}
+	w.NextCheckAt = time.Now().Add(interval)
+	if w.Triggered {
+		w.Triggered = false
+	} else {
+		w.Attempts++
+	}
+
+	stalled := false
+	if policy.MaxAttempts > 0 && w.Attempts >= policy.MaxAttempts {
+		logInfo("⏰ 记录ID %s 的字段检测已达到最大次数(%d次)，自动停止检测", recordID, policy.MaxAttempts)
+		stalled = true
+	} else if w.Deadline != nil && w.NextCheckAt.After(*w.Deadline) {
+		logInfo("⏰ 记录ID %s 的字段检测已到达截止时间(%s)，自动停止检测", recordID, w.Deadline.Format("2006-01-02 15:04:05"))
+		stalled = true
+	}
+	if stalled {
+		delete(s.watches, recordID)
+	}
+	s.persistLocked()
+	return *w, stalled
+}
+
+// markSatisfied 记录本次检测中新满足条件的检测字段，返回新满足条件的字段（按检测字段顺序）和更新后的检测任务
+func (s *WatchService) markSatisfied(recordID string, satisfied []string) ([]string, models.Watch) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.watches[recordID]
+	if !ok {
+		return nil, models.Watch{}
+	}
+
+	notified := make(map[string]bool)
+	for _, fieldName := range w.FilledFields {
+		notified[fieldName] = true
+	}
+	current := make(map[string]bool)
+	for _, fieldName := range satisfied {
+		current[fieldName] = true
+	}
+
+	var added []string
+	for _, fieldName := range w.CheckFields {
+		if current[fieldName] && !notified[fieldName] {
+			added = append(added, fieldName)
+		}
+	}
+
+	if len(added) > 0 {
+		w.FilledFields = append(w.FilledFields, added...)
+		s.persistLocked()
+	}
+	return added, *w
+}
+
+// remove 删除检测任务，任务已不存在（已被取消）时返回false
+func (s *WatchService) remove(recordID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.watches[recordID]; !ok {
+		return false
+	}
+	delete(s.watches, recordID)
+	s.persistLocked()
+	return true
+}
+
+// persistLocked 将当前检测任务写入文件，调用方需持有锁
+func (s *WatchService) persistLocked() {
+	watches := make([]*models.Watch, 0, len(s.watches))
+	for _, w := range s.watches {
+		watches = append(watches, w)
+	}
+	sort.Slice(watches, func(i, j int) bool {
+		return watches[i].CreatedAt.Before(watches[j].CreatedAt)
+	})
+
+	if err := s.store.Save(watches); err != nil {
+		logError("保存检测任务失败: %v", err)
+	}
+}
+
+// newWatch 根据表格配置创建检测任务
+func newWatch(table models.TableConfig, rule models.ConditionGroup, policy models.PollingPolicy, recordID string, firstCheck time.Time) *models.Watch {
+	now := time.Now()
+	watch := &models.Watch{
+		RecordID:    recordID,
+		AppToken:    table.AppToken,
+		TableID:     table.TableID,
+		TableName:   table.Name,
+		CheckFields: ConditionFieldNames(rule),
+		Rule:        rule,
+		Progress:    table.ProgressNotify,
+		Policy:      policy,
+		NextCheckAt: firstCheck,
+		CreatedAt:   now,
+	}
+	if policy.DeadlineSeconds > 0 {
+		deadline := now.Add(time.Duration(policy.DeadlineSeconds) * time.Second)
+		watch.Deadline = &deadline
+	}
+	return watch
+}
+
+// resolvePollingPolicy 为未设置的轮询策略项填充默认值
+// 既未设置最大检测次数也未设置截止时长时，使用默认最大检测次数
+func resolvePollingPolicy(policy models.PollingPolicy) models.PollingPolicy {
+	if policy.InitialDelaySeconds <= 0 {
+		policy.InitialDelaySeconds = int(DefaultWatchInitialDelay / time.Second)
+	}
+	if policy.BaseIntervalSeconds <= 0 {
+		policy.BaseIntervalSeconds = int(DefaultWatchBaseInterval / time.Second)
+	}
+	if policy.MaxIntervalSeconds <= 0 {
+		policy.MaxIntervalSeconds = int(DefaultWatchMaxInterval / time.Second)
+	}
+	if policy.MaxIntervalSeconds < policy.BaseIntervalSeconds {
+		policy.MaxIntervalSeconds = policy.BaseIntervalSeconds
+	}
+	if policy.BackoffFactor < 1 {
+		policy.BackoffFactor = DefaultWatchBackoffFactor
+	}
+	if policy.MaxAttempts <= 0 && policy.DeadlineSeconds <= 0 {
+		policy.MaxAttempts = DefaultWatchMaxChecks
+	}
+	return policy
+}
+
+// watchInterval 计算智能轮询间隔：基础间隔 * 退避系数^检测次数，最大不超过最大检测间隔
+func watchInterval(policy models.PollingPolicy, checkCount int) time.Duration {
+	base := time.Duration(policy.BaseIntervalSeconds) * time.Second
+	maxInterval := time.Duration(policy.MaxIntervalSeconds) * time.Second
+
+	interval := float64(base) * math.Pow(policy.BackoffFactor, float64(checkCount))
+	if interval > float64(maxInterval) {
+		return maxInterval
+	}
+	return time.Duration(interval)
+}
+
+// isRetryableWatchError 判断检测错误是否可以重试（网络错误或飞书API错误）
+func isRetryableWatchError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "network") || strings.Contains(msg, "timeout") || strings.Contains(msg, "API")
+}
+
+// findTableConfig 查找表格配置，兼容旧版本的单表格配置
+func findTableConfig(config *models.Config, appToken, tableID string) (models.TableConfig, bool) {
+	if len(config.Tables) == 0 {
+		// 旧格式：向后兼容
+		return models.TableConfig{
+			AppToken:    appToken,
+			TableID:     tableID,
+			Name:        "未命名表格",
+			CheckFields: config.CheckFields,
+		}, true
+	}
+
+	for _, table := range config.Tables {
+		if table.AppToken == appToken && table.TableID == tableID {
+			return table, true
+		}
+	}
+	return models.TableConfig{}, false
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"lark-record/models"
+)
+
+// WatchStore 检测任务的本地持久化存储，以JSON文件保存在配置文件旁
+type WatchStore struct {
+	path string
+}
+
+// NewWatchStore 创建新的检测任务存储
+func NewWatchStore(path string) *WatchStore {
+	if path == "" {
+		path = "./watches.json"
+	}
+	return &WatchStore{path: path}
+}
+
+// Load 从文件加载所有检测任务
+func (s *WatchStore) Load() ([]*models.Watch, error) {
+	var watches []*models.Watch
+	if _, err := readJSONFile(s.path, &watches); err != nil {
+		return nil, err
+	}
+	return watches, nil
+}
+
+// Save 保存所有检测任务到文件
+func (s *WatchStore) Save(watches []*models.Watch) error {
+	if watches == nil {
+		watches = []*models.Watch{}
+	}
+	return writeJSONFile(s.path, watches)
+}
@@ -306,6 +306,9 @@ document.addEventListener('DOMContentLoaded', function() {
         }
     }
 
+    // 尚未成功的提交：幂等键和请求内容
+    let pendingSubmission = null;
+
     // 提交记录
     submitRecordBtn.addEventListener('click', async function() {
         // 验证所有必填字段（同时查询input和textarea元素）
@@ -327,20 +330,28 @@ document.addEventListener('DOMContentLoaded', function() {
             submitRecordBtn.textContent = '提交中...';
             submitResult.textContent = '';
 
-            // 构建请求数据
+            // 构建请求数据，附带当前页面信息供默认值模板使用
             const requestData = {
                 app_token: selectedTable.app_token,
                 table_id: selectedTable.table_id,
-                fields: fieldsData
+                fields: fieldsData,
+                page: await getCurrentPage()
             };
 
+            // 每次提交使用一个幂等键，提交失败后重试相同内容时沿用，避免超时重试时重复新增记录
+            const body = JSON.stringify(requestData);
+            if (!pendingSubmission || pendingSubmission.body !== body) {
+                pendingSubmission = { key: crypto.randomUUID(), body: body };
+            }
+
             // 发送到后端
             const response = await fetch('http://localhost:8080/api/records', {
                 method: 'POST',
                 headers: {
-                    'Content-Type': 'application/json'
+                    'Content-Type': 'application/json',
+                    'Idempotency-Key': pendingSubmission.key
                 },
-                body: JSON.stringify(requestData)
+                body: body
             });
 
             const result = await response.json();
@@ -348,6 +359,7 @@ document.addEventListener('DOMContentLoaded', function() {
             if (!response.ok) {
                 throw new Error(result.error || '提交失败');
             }
+            pendingSubmission = null;
 
             // 成功
             showSubmitResult('记录成功！', true);
@@ -357,11 +369,14 @@ document.addEventListener('DOMContentLoaded', function() {
                 input.value = '';
             });
 
-            // 2秒后返回表格选择界面
-            setTimeout(() => {
+            // 5秒后返回表格选择界面，期间可以撤销误提交的记录
+            const returnTimer = setTimeout(() => {
                 showState('tableSelection');
                 submitResult.textContent = '';
-            }, 2000);
+            }, 5000);
+            if (result.submissionID) {
+                appendUndoLink(result.submissionID, returnTimer);
+            }
 
         } catch (error) {
             console.error('提交记录失败:', error);
@@ -372,6 +387,49 @@ document.addEventListener('DOMContentLoaded', function() {
         }
     });
 
+    // 在提交结果后添加撤销链接
+    function appendUndoLink(submissionID, returnTimer) {
+        const undoLink = document.createElement('a');
+        undoLink.href = '#';
+        undoLink.textContent = '撤销';
+        undoLink.style.marginLeft = '8px';
+        undoLink.addEventListener('click', async function(event) {
+            event.preventDefault();
+            clearTimeout(returnTimer);
+            undoLink.remove();
+            try {
+                const response = await fetch('http://localhost:8080/api/undo', {
+                    method: 'POST',
+                    headers: {
+                        'Content-Type': 'application/json'
+                    },
+                    body: JSON.stringify({ submission_id: submissionID })
+                });
+                const result = await response.json();
+                if (!response.ok) {
+                    throw new Error(result.error || '撤销失败');
+                }
+                const failed = (result.steps || []).filter(step => step.status === 'failed');
+                showSubmitResult(failed.length ? `已撤销，${failed.length} 个步骤失败` : '已撤销', true);
+            } catch (error) {
+                console.error('撤销提交失败:', error);
+                showSubmitResult('撤销失败: ' + error.message, false);
+            }
+        });
+        submitResult.appendChild(undoLink);
+    }
+
+    // 获取当前标签页的地址和标题
+    async function getCurrentPage() {
+        try {
+            const [tab] = await chrome.tabs.query({ active: true, currentWindow: true });
+            return { url: (tab && tab.url) || '', title: (tab && tab.title) || '' };
+        } catch (error) {
+            console.error('获取当前页面失败:', error);
+            return { url: '', title: '' };
+        }
+    }
+
     // 显示提交结果
     function showSubmitResult(message, success) {
         submitResult.textContent = message;